@@ -38,6 +38,22 @@ func loadContext(c *cli.Context) (d *model.Diagram, cfg *types.Config, err error
 	if err != nil {
 		return d, cfg, err
 	}
+	if seed := c.String("seed"); seed != "" {
+		cfg.GlobalSettings.Seed = seed
+	}
+	if lock := c.String("ipam-lock"); lock != "" {
+		// relative to the working directory, not to the config file
+		cfg.GlobalSettings.IPAMLock, err = filepath.Abs(lock)
+		if err != nil {
+			return d, cfg, err
+		}
+	}
+	if platform := c.String("platform"); platform != "" {
+		err = cfg.SetPlatform(platform)
+		if err != nil {
+			return d, cfg, err
+		}
+	}
 
 	return d, cfg, err
 }
@@ -133,6 +149,12 @@ func CmdBuild(c *cli.Context) error {
 		return err
 	}
 
+	// record assigned addresses for the next build
+	err = model.SaveIPAMLock(cfg, nm)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -144,6 +166,7 @@ func CmdParams(c *cli.Context) error {
 	}
 	name := c.String("output")
 	flagall := c.Bool("all")
+	flagsource := c.Bool("source")
 
 	nm, err := model.BuildNetworkModel(cfg, nd, false)
 	if err != nil {
@@ -170,7 +193,11 @@ func CmdParams(c *cli.Context) error {
 		sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
 		for _, k := range keys {
 			v := numbers[k]
-			lines = append(lines, fmt.Sprintf("%s {{ .%+v }} = %+v", ns.StringForMessage(), k, v))
+			line := fmt.Sprintf("%s {{ .%+v }} = %+v", ns.StringForMessage(), k, v)
+			if src := ns.GetParamSource(k); flagsource && src != "" {
+				line += fmt.Sprintf(" (%s)", src)
+			}
+			lines = append(lines, line)
 			// switch obj := ns.(type) {
 			// case *types.NetworkModel:
 			// 	lines = append(lines, fmt.Sprintf("network {{ .%+v }} = %+v", k, v))
@@ -343,6 +370,68 @@ func CmdData(c *cli.Context) error {
 	return err
 }
 
+func CmdIPAM(c *cli.Context) error {
+	nd, cfg, err := loadContext(c)
+	if err != nil {
+		return err
+	}
+	name := c.String("output")
+	domain := c.String("domain")
+
+	nm, err := model.BuildNetworkModel(cfg, nd, false)
+	if err != nil {
+		return err
+	}
+
+	records, err := visual.GetIPAMRecords(cfg, nm, c.String("layer"), domain)
+	if err != nil {
+		return err
+	}
+	var buf []byte
+	switch format := c.String("format"); format {
+	case "csv":
+		buf, err = visual.IPAMRecordsToCSV(records)
+	case "json":
+		buf, err = visual.IPAMRecordsToJSON(records)
+	case "hosts":
+		buf = visual.IPAMRecordsToHosts(records, domain)
+	default:
+		return fmt.Errorf("unknown format %s (csv, json or hosts)", format)
+	}
+	if err != nil {
+		return err
+	}
+
+	if dirname := c.String("zone"); dirname != "" {
+		zones, err := visual.IPAMRecordsToZones(records, domain)
+		if err != nil {
+			return err
+		}
+		err = os.MkdirAll(dirname, 0755)
+		if err != nil {
+			return err
+		}
+		for filename, zone := range zones {
+			err = outputString(filepath.Join(dirname, filename), zone)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return outputString(name, buf)
+}
+
+func CmdSchema(c *cli.Context) error {
+	name := c.String("output")
+
+	buf, err := types.ConfigJSONSchema()
+	if err != nil {
+		return err
+	}
+	return outputString(name, buf)
+}
+
 func CmdFiles(c *cli.Context) error {
 	nd, cfg, err := loadContext(c)
 	if err != nil {
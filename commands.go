@@ -7,8 +7,10 @@ var commands = []*cli.Command{
 	commandParams,
 	commandVisual,
 	commandData,
+	commandIPAM,
 	commandFiles,
 	commandClean,
+	commandSchema,
 }
 
 var commandBuild = &cli.Command{
@@ -22,6 +24,19 @@ var commandBuild = &cli.Command{
 			Usage:   "Specify the Config file.",
 			Value:   "config.yaml",
 		},
+		&cli.StringFlag{
+			Name:  "seed",
+			Usage: "Specify the seed of random and hash param_rule types (overrides global.seed in the config).",
+		},
+		&cli.StringFlag{
+			Name:  "ipam-lock",
+			Usage: "Specify the IPAM lock file to reuse assigned addresses (overrides global.ipam_lock in the config).",
+		},
+		&cli.StringFlag{
+			Name:  "platform",
+			Usage: "Specify the target platform (tinet, clab or command). Config templates and modules for other platforms are skipped.",
+			Value: "",
+		},
 		&cli.StringFlag{
 			Name:    "dir",
 			Aliases: []string{"d"},
@@ -54,11 +69,23 @@ var commandParams = &cli.Command{
 			Usage:   "Specify the Config file.",
 			Value:   "config.yaml",
 		},
+		&cli.StringFlag{
+			Name:  "seed",
+			Usage: "Specify the seed of random and hash param_rule types (overrides global.seed in the config).",
+		},
+		&cli.StringFlag{
+			Name:  "ipam-lock",
+			Usage: "Specify the IPAM lock file to reuse assigned addresses (overrides global.ipam_lock in the config).",
+		},
 		&cli.BoolFlag{
 			Name:    "all",
 			Aliases: []string{"a"},
 			Usage:   "Show all numbers including relative ones.",
 		},
+		&cli.BoolFlag{
+			Name:  "source",
+			Usage: "Show the source (label, object_values or the class name, e.g., nodeclass router) of the values given by users.",
+		},
 		&cli.BoolFlag{
 			Name:    "verbose",
 			Aliases: []string{"v"},
@@ -103,6 +130,60 @@ var commandData = &cli.Command{
 			Usage:   "Specify the Config file.",
 			Value:   "config.yaml",
 		},
+		&cli.StringFlag{
+			Name:  "seed",
+			Usage: "Specify the seed of random and hash param_rule types (overrides global.seed in the config).",
+		},
+		&cli.StringFlag{
+			Name:  "ipam-lock",
+			Usage: "Specify the IPAM lock file to reuse assigned addresses (overrides global.ipam_lock in the config).",
+		},
+	},
+}
+
+var commandIPAM = &cli.Command{
+	Name:   "ipam",
+	Usage:  "Output assigned addresses (loopbacks, interfaces, segments and management) in CSV, JSON or /etc/hosts format",
+	Action: CmdIPAM,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:    "config",
+			Aliases: []string{"c"},
+			Usage:   "Specify the Config file.",
+			Value:   "config.yaml",
+		},
+		&cli.StringFlag{
+			Name:  "ipam-lock",
+			Usage: "Specify the IPAM lock file to reuse assigned addresses (overrides global.ipam_lock in the config).",
+		},
+		&cli.StringFlag{
+			Name:    "layer",
+			Aliases: []string{"l"},
+			Usage:   "Specify layer name to output. If not given, all layers will be output.",
+			Value:   "",
+		},
+		&cli.StringFlag{
+			Name:    "format",
+			Aliases: []string{"f"},
+			Usage:   "Specify the output format (csv, json or hosts).",
+			Value:   "csv",
+		},
+		&cli.StringFlag{
+			Name:  "domain",
+			Usage: "Specify the DNS domain of the address names (e.g., r1-eth0.<layer>.<domain>).",
+			Value: "lab",
+		},
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   "Specify the output file. If not given, output to stdout.",
+			Value:   "",
+		},
+		&cli.StringFlag{
+			Name:  "zone",
+			Usage: "Specify the directory to output forward and reverse DNS zone files.",
+			Value: "",
+		},
 	},
 }
 
@@ -117,6 +198,11 @@ var commandFiles = &cli.Command{
 			Usage:   "Specify the Config file.",
 			Value:   "config.yaml",
 		},
+		&cli.StringFlag{
+			Name:  "platform",
+			Usage: "Specify the target platform (tinet, clab or command). Config templates and modules for other platforms are skipped.",
+			Value: "",
+		},
 		&cli.BoolFlag{
 			Name:    "verbose",
 			Aliases: []string{"v"},
@@ -136,6 +222,11 @@ var commandClean = &cli.Command{
 			Usage:   "Specify the Config file.",
 			Value:   "config.yaml",
 		},
+		&cli.StringFlag{
+			Name:  "platform",
+			Usage: "Specify the target platform (tinet, clab or command). Config templates and modules for other platforms are skipped.",
+			Value: "",
+		},
 		&cli.BoolFlag{
 			Name:    "verbose",
 			Aliases: []string{"v"},
@@ -147,3 +238,17 @@ var commandClean = &cli.Command{
 		},
 	},
 }
+
+var commandSchema = &cli.Command{
+	Name:   "schema",
+	Usage:  "Output JSON Schema of the Config file for editor completion",
+	Action: CmdSchema,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   "Specify the output file. If not given, output to stdout.",
+			Value:   "",
+		},
+	},
+}
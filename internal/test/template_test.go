@@ -0,0 +1,237 @@
+package example
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cpflat/dot2net/pkg/model"
+	"github.com/cpflat/dot2net/pkg/types"
+)
+
+// buildTestConfigFiles builds config files from given config and DOT contents in a temporary directory.
+// Additional files (e.g., template files) are written in the same directory.
+// It returns the directory that the config files are generated in.
+func buildTestConfigFiles(t *testing.T, configYAML string, dotContent string,
+	files map[string]string) (string, error) {
+	t.Helper()
+	tmpDir := t.TempDir()
+
+	configFile := filepath.Join(tmpDir, "input.yaml")
+	dotFile := filepath.Join(tmpDir, "input.dot")
+	if err := os.WriteFile(configFile, []byte(configYAML), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	if err := os.WriteFile(dotFile, []byte(dotContent), 0644); err != nil {
+		t.Fatalf("Failed to write dot file: %v", err)
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file %s: %v", name, err)
+		}
+	}
+
+	origDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	defer os.Chdir(origDir)
+
+	cfg, err := types.LoadConfig(configFile)
+	if err != nil {
+		return tmpDir, err
+	}
+	nd, err := model.DiagramFromDotFile(dotFile)
+	if err != nil {
+		return tmpDir, err
+	}
+	nm, err := model.BuildNetworkModel(cfg, nd, false)
+	if err != nil {
+		return tmpDir, err
+	}
+	return tmpDir, model.BuildConfigFiles(cfg, nm, false)
+}
+
+func checkFileContent(t *testing.T, dir string, path string, expected string) {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join(dir, path))
+	if err != nil {
+		t.Errorf("Failed to read %s: %v", path, err)
+		return
+	}
+	if string(content) != expected {
+		t.Errorf("content of %s mismatch:\n  got:\n%s\n  expected:\n%s", path, string(content), expected)
+	}
+}
+
+// TestStructuredTemplate tests config templates with the structured object context
+func TestStructuredTemplate(t *testing.T) {
+	configYAML := `
+name: structured_test
+file:
+  - name: summary.txt
+    scope: node
+layer:
+  - name: ip
+    default_connect: true
+    policy:
+      - name: p2p
+        range: 10.0.0.0/16
+        prefix: 30
+param_rule:
+  - name: vlans
+    mode: attach
+    source:
+      type: list
+      values:
+        - { vlan_id: "10" }
+        - { vlan_id: "20" }
+nodeclass:
+  - name: router
+    params: [vlans]
+    config:
+      - file: summary.txt
+        structured: true
+        template:
+          - "{{ .name }} in{{ range .Obj.Groups }} {{ .Name }}{{ end }}"
+          - "{{ range .Obj.Interfaces }}{{ .Name }} {{ .Param \"ip_addr\" }}{{ range .Neighbors \"ip\" }} -> {{ .Node.Name }}{{ end }}"
+          - "{{ end }}neighbors:{{ range .Obj.Neighbors \"ip\" }} {{ .Node.Name }}{{ end }}"
+          - "vlans:{{ range .Obj.Values \"vlans\" }} {{ .Param \"vlan_id\" }}{{ end }}"
+interfaceclass:
+  - name: default
+    policy: [p2p]
+`
+	dotContent := `
+graph {
+  subgraph cluster_core { r1 [xlabel="router"]; }
+  r2 [xlabel="router"];
+  r3 [xlabel="router"];
+  r1 -- r2;
+  r1 -- r3;
+}
+`
+	dir, err := buildTestConfigFiles(t, configYAML, dotContent, nil)
+	if err != nil {
+		t.Fatalf("Failed to build config files: %v", err)
+	}
+	checkFileContent(t, dir, "r1/summary.txt", `r1 in cluster_core
+net0 10.0.0.1 -> r2
+net1 10.0.0.5 -> r3
+neighbors: r2 r3
+vlans: 10 20`)
+}
+
+// TestTemplateLibrary tests shared templates defined in template_library
+func TestTemplateLibrary(t *testing.T) {
+	configYAML := `
+name: library_test
+global:
+  path: local
+template_library:
+  template:
+    - '{{ define "hostname" }}hostname {{ .name }}{{ end }}'
+  files: ["lib/*.tmpl"]
+file:
+  - name: frr.conf
+    scope: node
+nodeclass:
+  - name: router
+    config:
+      - file: frr.conf
+        template:
+          - '{{ template "hostname" . }}'
+          - '{{ template "neighbor" dict "addr" "10.0.0.2" "as" .as }}'
+`
+	files := map[string]string{
+		"lib/bgp.tmpl": `text out of define blocks is ignored
+{{ define "neighbor" }}neighbor {{ .addr }} remote-as {{ .as }}{{ end }}
+`,
+	}
+	dotContent := `graph { r1 [xlabel="router, as=65010"]; }`
+
+	dir, err := buildTestConfigFiles(t, configYAML, dotContent, files)
+	if err != nil {
+		t.Fatalf("Failed to build config files: %v", err)
+	}
+	checkFileContent(t, dir, "r1/frr.conf", "hostname r1\nneighbor 10.0.0.2 remote-as 65010")
+}
+
+// TestPlatformSelection tests config templates and modules filtered by the selected platform
+func TestPlatformSelection(t *testing.T) {
+	configYAML := `
+name: platform_test
+global:
+  platform: clab
+file:
+  - name: tinet.txt
+    scope: node
+  - name: clab.txt
+    scope: node
+  - name: common.txt
+    scope: node
+nodeclass:
+  - name: router
+    config:
+      - file: tinet.txt
+        platform: [tinet]
+        template: ["tinet {{ .name }}"]
+      - file: clab.txt
+        platform: [clab]
+        template: ["clab {{ .name }}"]
+      - file: common.txt
+        depends: [tinet_name, clab_name]
+        template: ["{{ .self_tinet_name }}{{ .self_clab_name }}"]
+      - name: tinet_name
+        platform: [tinet]
+        template: ["tinet"]
+      - name: clab_name
+        platform: [clab]
+        template: ["clab"]
+`
+	dotContent := `graph { r1 [xlabel="router"]; }`
+
+	dir, err := buildTestConfigFiles(t, configYAML, dotContent, nil)
+	if err != nil {
+		t.Fatalf("Failed to build config files: %v", err)
+	}
+	checkFileContent(t, dir, "r1/clab.txt", "clab r1")
+	checkFileContent(t, dir, "r1/common.txt", "clab")
+	if _, err := os.Stat(filepath.Join(dir, "r1/tinet.txt")); !os.IsNotExist(err) {
+		t.Errorf("tinet.txt should not be generated for platform clab")
+	}
+
+	t.Run("Modules", func(t *testing.T) {
+		configFile := filepath.Join(t.TempDir(), "input.yaml")
+		content := "name: platform_test\nmodule: [tinet, containerlab]\n"
+		if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := types.LoadConfig(configFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := cfg.SetPlatform(types.OutputClab); err != nil {
+			t.Fatal(err)
+		}
+		if err := model.LoadModules(cfg); err != nil {
+			t.Fatal(err)
+		}
+		if len(cfg.LoadedModules) != 1 {
+			t.Errorf("number of loaded modules mismatch (%v)", len(cfg.LoadedModules))
+		}
+	})
+
+	t.Run("Invalid_Platform", func(t *testing.T) {
+		cfg := &types.Config{}
+		if err := cfg.SetPlatform("docker"); err == nil {
+			t.Errorf("expected error for unknown platform")
+		}
+	})
+}
@@ -0,0 +1,1506 @@
+package example
+
+import (
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/cpflat/dot2net/pkg/model"
+	"github.com/cpflat/dot2net/pkg/types"
+	"github.com/cpflat/dot2net/pkg/visual"
+)
+
+// buildTestModel builds a NetworkModel from given config and DOT contents.
+// Additional files (e.g., template DOT files) are written in the same directory.
+func buildTestModel(t *testing.T, configYAML string, dotContent string,
+	files map[string]string) (*types.NetworkModel, error) {
+	t.Helper()
+	_, nm, err := buildTestConfigModel(t, configYAML, dotContent, files)
+	return nm, err
+}
+
+// buildTestConfigModel is buildTestModel also returning the loaded Config.
+func buildTestConfigModel(t *testing.T, configYAML string, dotContent string,
+	files map[string]string) (*types.Config, *types.NetworkModel, error) {
+	t.Helper()
+	tmpDir := t.TempDir()
+
+	configFile := filepath.Join(tmpDir, "test.yaml")
+	dotFile := filepath.Join(tmpDir, "test.dot")
+	if err := os.WriteFile(configFile, []byte(configYAML), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	if err := os.WriteFile(dotFile, []byte(dotContent), 0644); err != nil {
+		t.Fatalf("Failed to write dot file: %v", err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file %s: %v", name, err)
+		}
+	}
+
+	cfg, err := types.LoadConfig(configFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	nd, err := model.DiagramFromDotFile(dotFile)
+	if err != nil {
+		return cfg, nil, err
+	}
+	nm, err := model.BuildNetworkModel(cfg, nd, false)
+	return cfg, nm, err
+}
+
+func checkNodeParam(t *testing.T, nm *types.NetworkModel, node string, key string, expected string) {
+	t.Helper()
+	n, ok := nm.NodeByName(node)
+	if !ok {
+		t.Errorf("node %s not found", node)
+		return
+	}
+	val, err := n.GetParamValue(key)
+	if err != nil {
+		t.Errorf("param %s of node %s not found: %v", key, node, err)
+		return
+	}
+	if val != expected {
+		t.Errorf("param %s of node %s mismatch: expected %s, got %s", key, node, expected, val)
+	}
+}
+
+// TestPodTemplates tests instantiation of pod templates
+func TestPodTemplates(t *testing.T) {
+	t.Run("Subgraph_Template", func(t *testing.T) {
+		nm, err := buildTestModel(t, `
+name: pod_test
+global:
+  path: local
+podtemplate:
+  - name: pod
+    subgraph: cluster_pod
+    count: 2
+    groupclass: [podgroup]
+    attach:
+      uplink: ["spine1", "spine2"]
+groupclass:
+  - name: podgroup
+nodeclass:
+  - name: router
+`, `
+digraph {
+  spine1 [xlabel="router"];
+  spine2 [xlabel="router"];
+  subgraph cluster_pod {
+    leaf1 [xlabel="router"];
+    leaf2 [xlabel="router"];
+    leaf1 -> leaf2;
+    leaf1 -> uplink;
+  }
+}
+`, nil)
+		if err != nil {
+			t.Fatalf("Failed to build network model: %v", err)
+		}
+		// 2 spines + 2 pods * 2 leaves
+		if len(nm.Nodes) != 6 {
+			t.Errorf("number of nodes mismatch (%v)", len(nm.Nodes))
+		}
+		// 2 pods * (1 internal link + 2 uplinks)
+		if len(nm.Connections) != 6 {
+			t.Errorf("number of connections mismatch (%v)", len(nm.Connections))
+		}
+		if _, ok := nm.NodeByName("leaf1"); ok {
+			t.Errorf("template node remains in topology")
+		}
+		checkNodeParam(t, nm, "pod1_leaf1", "pod_index", "1")
+		checkNodeParam(t, nm, "pod2_leaf2", "pod_index", "2")
+
+		node, _ := nm.NodeByName("pod2_leaf1")
+		if len(node.Groups) != 1 || node.Groups[0].Name != "pod2" {
+			t.Errorf("group of pod2_leaf1 mismatch")
+		} else if !node.Groups[0].HasClass("podgroup") {
+			t.Errorf("class of group pod2 mismatch")
+		}
+		spine, _ := nm.NodeByName("spine1")
+		if len(spine.Interfaces) != 2 {
+			t.Errorf("number of spine1 interfaces mismatch (%v)", len(spine.Interfaces))
+		}
+	})
+
+	t.Run("File_Template", func(t *testing.T) {
+		nm, err := buildTestModel(t, `
+name: pod_test
+global:
+  path: local
+podtemplate:
+  - name: rack
+    file: rack.dot
+    count: 3
+    prefix: "r{{ .index }}-"
+    group: "rack{{ .index }}"
+    index_param: rack_id
+    attach:
+      core: ["core{{ .index }}"]
+`, `
+digraph {
+  core1; core2; core3;
+}
+`, map[string]string{"rack.dot": `
+digraph {
+  tor -> server;
+  tor -> core;
+}
+`})
+		if err != nil {
+			t.Fatalf("Failed to build network model: %v", err)
+		}
+		if len(nm.Nodes) != 9 {
+			t.Errorf("number of nodes mismatch (%v)", len(nm.Nodes))
+		}
+		checkNodeParam(t, nm, "r3-server", "rack_id", "3")
+		core, _ := nm.NodeByName("core2")
+		if len(core.Interfaces) != 1 || core.Interfaces[0].Opposite.Node.Name != "r2-tor" {
+			t.Errorf("attachment of core2 mismatch")
+		}
+	})
+
+	t.Run("Nested_Groups", func(t *testing.T) {
+		nm, err := buildTestModel(t, `
+name: pod_test
+podtemplate:
+  - name: pod
+    subgraph: cluster_pod
+    count: 2
+`, `
+digraph {
+  subgraph cluster_dc {
+    spine1;
+    subgraph cluster_pod {
+      subgraph cluster_rack { leaf1; }
+      leaf2;
+      leaf1 -> leaf2;
+    }
+  }
+}
+`, nil)
+		if err != nil {
+			t.Fatalf("Failed to build network model: %v", err)
+		}
+		// groups are ordered from the innermost one
+		for name, expected := range map[string][]string{
+			"pod1_leaf1": {"pod1_cluster_rack", "pod1", "cluster_dc"},
+			"pod2_leaf2": {"pod2", "cluster_dc"},
+			"spine1":     {"cluster_dc"},
+		} {
+			node, _ := nm.NodeByName(name)
+			var groups []string
+			for _, group := range node.Groups {
+				groups = append(groups, group.Name)
+			}
+			if !reflect.DeepEqual(groups, expected) {
+				t.Errorf("groups of %s mismatch: expected %v, got %v", name, expected, groups)
+			}
+		}
+	})
+
+	t.Run("Boundary_Crossing_Link", func(t *testing.T) {
+		_, err := buildTestModel(t, `
+name: pod_test
+podtemplate:
+  - name: pod
+    subgraph: cluster_pod
+    count: 2
+`, `
+digraph {
+  subgraph cluster_pod { leaf1; }
+  spine1 -> leaf1;
+}
+`, nil)
+		if err == nil || !strings.Contains(err.Error(), "crosses the boundary") {
+			t.Errorf("Expected boundary crossing error, got: %v", err)
+		}
+	})
+
+	t.Run("Missing_Attachment_Target", func(t *testing.T) {
+		_, err := buildTestModel(t, `
+name: pod_test
+podtemplate:
+  - name: pod
+    subgraph: cluster_pod
+    count: 1
+    attach:
+      uplink: ["spine9"]
+`, `
+digraph {
+  subgraph cluster_pod { leaf1 -> uplink; }
+}
+`, nil)
+		if err == nil || !strings.Contains(err.Error(), "not found") {
+			t.Errorf("Expected missing target error, got: %v", err)
+		}
+	})
+}
+
+// TestDotAttributes tests DOT attributes exposed as parameters
+func TestDotAttributes(t *testing.T) {
+	dotContent := `
+digraph {
+  subgraph cluster_rack1 {
+    rack="r1";
+    r1 [xlabel="router", rack="r1", color="#ff0000"];
+  }
+  r2 [xlabel="router"];
+  r1 -> r2 [bw="10G", tailbw="25G"];
+}
+`
+	t.Run("Enabled", func(t *testing.T) {
+		nm, err := buildTestModel(t, `
+name: dot_attr_test
+global:
+  dot_attributes: true
+nodeclass:
+  - name: router
+`, dotContent, nil)
+		if err != nil {
+			t.Fatalf("Failed to build network model: %v", err)
+		}
+		checkNodeParam(t, nm, "r1", "dot_attr_rack", "r1")
+		checkNodeParam(t, nm, "r1", "dot_attr_color", "#ff0000")
+
+		conn := nm.Connections[0]
+		expected := map[string]string{
+			"conn": "10G",
+			"src":  "25G", // tailbw overrides bw
+			"dst":  "10G",
+		}
+		for target, ns := range map[string]interface {
+			GetParamValue(string) (string, error)
+		}{"conn": conn, "src": conn.Src, "dst": conn.Dst} {
+			val, err := ns.GetParamValue("dot_attr_bw")
+			if err != nil {
+				t.Errorf("dot_attr_bw of %s not found: %v", target, err)
+			} else if val != expected[target] {
+				t.Errorf("dot_attr_bw of %s mismatch: expected %s, got %s", target, expected[target], val)
+			}
+		}
+		group, _ := nm.GroupByName("cluster_rack1")
+		if val, err := group.GetParamValue("dot_attr_rack"); err != nil || val != "r1" {
+			t.Errorf("dot_attr_rack of group mismatch: %v, %v", val, err)
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		nm, err := buildTestModel(t, `
+name: dot_attr_test
+nodeclass:
+  - name: router
+`, dotContent, nil)
+		if err != nil {
+			t.Fatalf("Failed to build network model: %v", err)
+		}
+		node, _ := nm.NodeByName("r1")
+		if node.HasParam("dot_attr_rack") {
+			t.Errorf("dot_attr_rack should not be given without dot_attributes")
+		}
+	})
+}
+
+// TestLabelGrammar tests value labels with quoting, escaping and list values
+func TestLabelGrammar(t *testing.T) {
+	nm, err := buildTestModel(t, `
+name: label_test
+nodeclass:
+  - name: router
+`, `
+digraph {
+  r1 [xlabel="router, desc=\"uplink, primary\", note=see #1, comm=[65000:1, 65000:2]"];
+}
+`, nil)
+	if err != nil {
+		t.Fatalf("Failed to build network model: %v", err)
+	}
+	checkNodeParam(t, nm, "r1", "desc", "uplink, primary")
+	checkNodeParam(t, nm, "r1", "note", "see #1")
+	checkNodeParam(t, nm, "r1", "comm", "65000:1 65000:2")
+}
+
+// TestClassifyRules tests automatic class assignment with classify rules
+func TestClassifyRules(t *testing.T) {
+	configYAML := `
+name: classify_test
+classify:
+  - class: spine
+    name: "^spine[0-9]+$"
+  - class: leaf
+    neighbor_classes: [spine]
+  - class: server
+    root: spine
+    distance: 2
+  - class: edge
+    group: cluster_pod1
+    max_degree: 1
+  - class: fabric
+    target: connection
+    node_classes: [spine]
+    opposite_node_classes: [leaf]
+  - class: access
+    target: interface
+    node_classes: [leaf]
+    opposite_node_classes: [server]
+nodeclass:
+  - name: default
+  - name: spine
+  - name: leaf
+  - name: server
+  - name: edge
+interfaceclass:
+  - name: access
+connectionclass:
+  - name: fabric
+`
+	dotContent := `
+graph {
+  spine1 -- leaf1;
+  spine1 -- leaf2;
+  leaf1 -- srv1;
+  subgraph cluster_pod1 { leaf2 -- srv2; }
+}
+`
+	nm, err := buildTestModel(t, configYAML, dotContent, nil)
+	if err != nil {
+		t.Fatalf("Failed to build network model: %v", err)
+	}
+
+	expected := map[string][]string{
+		"spine1": {"spine"},
+		"leaf1":  {"leaf"},
+		"leaf2":  {"leaf"},
+		"srv1":   {"server"},
+		"srv2":   {"server", "edge"},
+	}
+	for name, classes := range expected {
+		node, ok := nm.NodeByName(name)
+		if !ok {
+			t.Fatalf("node %s not found", name)
+		}
+		if strings.Join(node.ClassLabels(), ",") != strings.Join(classes, ",") {
+			t.Errorf("classes of %s mismatch: expected %v, got %v", name, classes, node.ClassLabels())
+		}
+	}
+
+	for _, conn := range nm.Connections {
+		isFabric := conn.Src.Node.HasClass("spine") || conn.Dst.Node.HasClass("spine")
+		if conn.HasClass("fabric") != isFabric {
+			t.Errorf("fabric class of %s -- %s mismatch", conn.Src.Node.Name, conn.Dst.Node.Name)
+		}
+	}
+	leaf1, _ := nm.NodeByName("leaf1")
+	for _, iface := range leaf1.Interfaces {
+		isAccess := iface.Opposite.Node.Name == "srv1"
+		if iface.HasClass("access") != isAccess {
+			t.Errorf("access class of leaf1 interface to %s mismatch", iface.Opposite.Node.Name)
+		}
+	}
+
+	t.Run("Interface_Name", func(t *testing.T) {
+		nameYAML := `
+name: classify_test
+classify:
+  - class: uplink
+    target: interface
+    name: "^eth"
+nodeclass:
+  - name: default
+interfaceclass:
+  - name: uplink
+`
+		nm, err := buildTestModel(t, nameYAML, `
+graph {
+  r1:eth0 -- r2:Ethernet1;
+  r1:eth1 -- r3:lo1;
+}
+`, nil)
+		if err != nil {
+			t.Fatalf("Failed to build network model: %v", err)
+		}
+		for _, node := range nm.Nodes {
+			for _, iface := range node.Interfaces {
+				expected := strings.HasPrefix(iface.Name, "eth")
+				if iface.HasClass("uplink") != expected {
+					t.Errorf("uplink class of %s mismatch (expected %v)", iface, expected)
+				}
+			}
+		}
+
+		// interfaces without port names are named after classification
+		_, err = buildTestModel(t, nameYAML, "graph {\n  r1:eth0 -- r2;\n}\n", nil)
+		if err == nil || !strings.Contains(err.Error(), "named in DOT") {
+			t.Errorf("Expected name error, got: %v", err)
+		}
+	})
+}
+
+// TestObjectValues tests per-object values given in the object values file
+func TestObjectValues(t *testing.T) {
+	configYAML := `
+name: object_values_test
+global:
+  path: local
+object_values: values.yaml
+nodeclass:
+  - name: router
+    values:
+      as: "65000"
+      serial: "NONE"
+      image: frr
+`
+	dotContent := `
+graph {
+  subgraph cluster_pod1 { r1 [xlabel="router, as=65100"]; }
+  r2 [xlabel="router"];
+  r1 -- r2;
+}
+`
+	valuesYAML := `
+node:
+  r1:
+    as: 65001
+    serial: ABC123
+  r2:
+    neighbors: [10.0.0.1, 10.0.0.2]
+interface:
+  r1:net0:
+    description: uplink
+connection:
+  conn0:
+    mtu: 9000
+group:
+  cluster_pod1:
+    site: tokyo
+`
+	nm, err := buildTestModel(t, configYAML, dotContent, map[string]string{"values.yaml": valuesYAML})
+	if err != nil {
+		t.Fatalf("Failed to build network model: %v", err)
+	}
+	// DOT labels > object values > class values
+	checkNodeParam(t, nm, "r1", "as", "65100")
+	checkNodeParam(t, nm, "r1", "serial", "ABC123")
+	checkNodeParam(t, nm, "r1", "image", "frr")
+	checkNodeParam(t, nm, "r2", "neighbors", "10.0.0.1 10.0.0.2")
+
+	r1, _ := nm.NodeByName("r1")
+	iface, _ := r1.InterfaceByName("net0")
+	if val, err := iface.GetParamValue("description"); err != nil || val != "uplink" {
+		t.Errorf("description of r1:net0 mismatch: %v, %v", val, err)
+	}
+	// connection values are also given to both interfaces
+	if val, err := iface.GetParamValue("mtu"); err != nil || val != "9000" {
+		t.Errorf("mtu of r1:net0 mismatch: %v, %v", val, err)
+	}
+	group, _ := nm.GroupByName("cluster_pod1")
+	if val, err := group.GetParamValue("site"); err != nil || val != "tokyo" {
+		t.Errorf("site of cluster_pod1 mismatch: %v, %v", val, err)
+	}
+
+	t.Run("Undefined_Object", func(t *testing.T) {
+		_, err := buildTestModel(t, configYAML, dotContent, map[string]string{"values.yaml": "node:\n  r9:\n    as: 1\n"})
+		if err == nil || !strings.Contains(err.Error(), "node r9 not found") {
+			t.Errorf("Expected undefined node error, got: %v", err)
+		}
+	})
+
+	t.Run("Unknown_Section", func(t *testing.T) {
+		_, err := buildTestModel(t, configYAML, dotContent, map[string]string{"values.yaml": "nodes:\n  r1:\n    as: 1\n"})
+		if err == nil || !strings.Contains(err.Error(), "did you mean node?") {
+			t.Errorf("Expected unknown section error, got: %v", err)
+		}
+	})
+}
+
+func TestValueConflict(t *testing.T) {
+	configYAML := `
+name: value_conflict_test
+global:
+  path: local
+  value_conflict: %s
+  value_precedence: [%s]
+nodeclass:
+  - name: router
+    values:
+      as: "65000"
+connectionclass:
+  - name: fabric
+    values:
+      mtu: "1500"
+      vlan: "10"
+  - name: jumbo
+    values:
+      mtu: "9000"
+`
+	dotContent := `
+graph {
+  r1 [xlabel="router, as=65100"];
+  r2 [xlabel="router"];
+  r1 -- r2 [label="fabric, jumbo"];
+}
+`
+	nm, err := buildTestModel(t, fmt.Sprintf(configYAML, "ignore", ""), dotContent, nil)
+	if err != nil {
+		t.Fatalf("Failed to build network model: %v", err)
+	}
+	// DOT label > class values
+	checkNodeParam(t, nm, "r1", "as", "65100")
+	checkNodeParam(t, nm, "r2", "as", "65000")
+
+	r1, _ := nm.NodeByName("r1")
+	if src := r1.GetParamSource("as"); src != "label" {
+		t.Errorf("source of as mismatch: %s", src)
+	}
+	// later class overrides earlier class
+	iface, _ := r1.InterfaceByName("net0")
+	if val, err := iface.GetParamValue("mtu"); err != nil || val != "9000" {
+		t.Errorf("mtu of r1:net0 mismatch: %v, %v", val, err)
+	}
+	if src := iface.GetParamSource("mtu"); src != "connection connectionclass jumbo" {
+		t.Errorf("source of mtu mismatch: %s", src)
+	}
+	if src := iface.GetParamSource("vlan"); src != "connection connectionclass fabric" {
+		t.Errorf("source of vlan mismatch: %s", src)
+	}
+
+	t.Run("Class_Precedence", func(t *testing.T) {
+		nm, err := buildTestModel(t, fmt.Sprintf(configYAML, "ignore", "class, label"), dotContent, nil)
+		if err != nil {
+			t.Fatalf("Failed to build network model: %v", err)
+		}
+		checkNodeParam(t, nm, "r1", "as", "65000")
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		_, err := buildTestModel(t, fmt.Sprintf(configYAML, "error", ""), dotContent, nil)
+		if err == nil || !strings.Contains(err.Error(), "conflicting values of") {
+			t.Errorf("Expected conflict error, got: %v", err)
+		}
+	})
+
+	t.Run("Precedence_Not_Reported", func(t *testing.T) {
+		// a DOT label overriding a class value is not a conflict
+		noClassConflict := strings.Replace(dotContent, `label="fabric, jumbo"`, `label="fabric"`, 1)
+		nm, err := buildTestModel(t, fmt.Sprintf(configYAML, "error", ""), noClassConflict, nil)
+		if err != nil {
+			t.Fatalf("Unexpected conflict error: %v", err)
+		}
+		checkNodeParam(t, nm, "r1", "as", "65100")
+	})
+
+	t.Run("Invalid_Mode", func(t *testing.T) {
+		_, err := buildTestModel(t, fmt.Sprintf(configYAML, "fail", ""), dotContent, nil)
+		if err == nil || !strings.Contains(err.Error(), "value_conflict") {
+			t.Errorf("Expected invalid value_conflict error, got: %v", err)
+		}
+	})
+}
+
+func TestSeededParameterRules(t *testing.T) {
+	configYAML := `
+name: seeded_test
+global:
+  path: local
+  seed: %s
+param_rule:
+  - name: bgp_password
+    type: password
+    length: 16
+  - name: rid
+    type: random_int
+    min: 1
+    max: 65534
+nodeclass:
+  - name: router
+    params: [bgp_password, rid]
+`
+	dotContent := `
+graph {
+  r1 [xlabel="router"];
+  r2 [xlabel="router"];
+  r1 -- r2;
+}
+`
+	build := func(seed string) map[string]string {
+		nm, err := buildTestModel(t, fmt.Sprintf(configYAML, seed), dotContent, nil)
+		if err != nil {
+			t.Fatalf("Failed to build network model: %v", err)
+		}
+		ret := map[string]string{}
+		for _, node := range nm.Nodes {
+			for _, k := range []string{"bgp_password", "rid"} {
+				val, err := node.GetParamValue(k)
+				if err != nil {
+					t.Fatalf("%s of %s not found: %v", k, node.Name, err)
+				}
+				ret[node.Name+"."+k] = val
+			}
+		}
+		return ret
+	}
+
+	first := build("lab")
+	if len(first["r1.bgp_password"]) != 16 || first["r1.bgp_password"] == first["r2.bgp_password"] {
+		t.Errorf("invalid passwords: %v", first)
+	}
+	if second := build("lab"); !reflect.DeepEqual(first, second) {
+		t.Errorf("parameters not reproducible: %v, %v", first, second)
+	}
+	if other := build("other"); reflect.DeepEqual(first, other) {
+		t.Errorf("parameters not changed by seed: %v", first)
+	}
+
+	t.Run("Invalid_Range", func(t *testing.T) {
+		_, err := buildTestModel(t, "name: x\nparam_rule:\n  - name: rid\n    type: random_int\n", dotContent, nil)
+		if err == nil || !strings.Contains(err.Error(), "max should be larger than min") {
+			t.Errorf("Expected invalid range error, got: %v", err)
+		}
+	})
+}
+
+func TestScopedParameterRules(t *testing.T) {
+	configYAML := `
+name: scoped_test
+global:
+  path: local
+podtemplate:
+  - name: pod
+    subgraph: cluster_pod
+    count: 2
+    groupclass: [podgroup]
+    attach:
+      uplink: ["spine1"]
+groupclass:
+  - name: podgroup
+param_rule:
+  - name: leaf_id
+    assign: group
+    min: 1
+  - name: as
+    assign: group
+    group_class: podgroup
+    min: 65001
+    offset: "{{ mul (sub .pod_index 1) 100 }}"
+  - name: port
+    assign: node
+    min: 1
+nodeclass:
+  - name: leaf
+    params: [leaf_id, as]
+  - name: spine
+    params: [leaf_id]
+interfaceclass:
+  - name: port
+    params: [port]
+`
+	dotContent := `
+digraph {
+  spine1 [xlabel="spine"];
+  subgraph cluster_pod {
+    leaf1 [xlabel="leaf"];
+    leaf2 [xlabel="leaf"];
+    leaf1 -> leaf2 [taillabel="port", headlabel="port"];
+    leaf1 -> uplink [taillabel="port", headlabel="port"];
+    leaf2 -> uplink [taillabel="port", headlabel="port"];
+  }
+}
+`
+	nm, err := buildTestModel(t, configYAML, dotContent, nil)
+	if err != nil {
+		t.Fatalf("Failed to build network model: %v", err)
+	}
+	// numbering restarts in each group, and nodes without group are numbered together
+	checkNodeParam(t, nm, "pod1_leaf1", "leaf_id", "1")
+	checkNodeParam(t, nm, "pod1_leaf2", "leaf_id", "2")
+	checkNodeParam(t, nm, "pod2_leaf1", "leaf_id", "1")
+	checkNodeParam(t, nm, "pod2_leaf2", "leaf_id", "2")
+	checkNodeParam(t, nm, "spine1", "leaf_id", "1")
+	// offset by group parameters
+	checkNodeParam(t, nm, "pod1_leaf2", "as", "65002")
+	checkNodeParam(t, nm, "pod2_leaf1", "as", "65101")
+
+	// numbering of interfaces restarts in each node
+	for _, name := range []string{"pod1_leaf1", "pod2_leaf2", "spine1"} {
+		node, _ := nm.NodeByName(name)
+		for i, iface := range node.Interfaces {
+			val, err := iface.GetParamValue("port")
+			if err != nil || val != strconv.Itoa(i+1) {
+				t.Errorf("port of %s mismatch: %v, %v", iface.StringForMessage(), val, err)
+			}
+		}
+	}
+
+	t.Run("Node_Scope_For_Nodes", func(t *testing.T) {
+		_, err := buildTestModel(t, strings.Replace(configYAML, "assign: group\n    min: 1", "assign: node\n    min: 1", 1), dotContent, nil)
+		if err == nil || !strings.Contains(err.Error(), "available only for interfaces") {
+			t.Errorf("Expected assign error, got: %v", err)
+		}
+	})
+
+	t.Run("Nested_Group_In_Pod", func(t *testing.T) {
+		nm, err := buildTestModel(t, `
+name: scoped_test
+podtemplate:
+  - name: pod
+    subgraph: cluster_pod
+    count: 2
+    groupclass: [podgroup]
+groupclass:
+  - name: podgroup
+param_rule:
+  - name: rack_id
+    assign: group
+    min: 1
+  - name: pod_id
+    assign: group
+    group_class: podgroup
+    min: 1
+nodeclass:
+  - name: leaf
+    params: [rack_id, pod_id]
+`, `
+digraph {
+  subgraph cluster_pod {
+    subgraph cluster_rack {
+      leaf1 [xlabel="leaf"];
+      leaf2 [xlabel="leaf"];
+    }
+    leaf3 [xlabel="leaf"];
+  }
+}
+`, nil)
+		if err != nil {
+			t.Fatalf("Failed to build network model: %v", err)
+		}
+		// numbering restarts in the innermost group (rack), or in the group with group_class (pod)
+		checkNodeParam(t, nm, "pod2_leaf1", "rack_id", "1")
+		checkNodeParam(t, nm, "pod2_leaf2", "rack_id", "2")
+		checkNodeParam(t, nm, "pod2_leaf3", "rack_id", "1")
+		checkNodeParam(t, nm, "pod2_leaf1", "pod_id", "1")
+		checkNodeParam(t, nm, "pod2_leaf2", "pod_id", "2")
+		checkNodeParam(t, nm, "pod2_leaf3", "pod_id", "3")
+	})
+
+	t.Run("MAC_Without_Offset", func(t *testing.T) {
+		mac := strings.Replace(configYAML, "  - name: port\n    assign: node\n", "  - name: port\n    type: mac\n    assign: node\n", 1)
+		_, err := buildTestModel(t, mac, dotContent, nil)
+		if err == nil || !strings.Contains(err.Error(), "requires offset") {
+			t.Errorf("Expected offset error, got: %v", err)
+		}
+	})
+}
+
+func TestComputedParameters(t *testing.T) {
+	configYAML := `
+name: computed_test
+global:
+  path: local
+param_rule:
+  - name: router_id
+    min: 1
+  - name: as
+    type: expr
+    expr: "{{ add 65000 .router_id }}"
+  - name: vni
+    type: expr
+    expr: "{{ add 10000 .vlan }}"
+    header: "VNI"
+  - name: peer_desc
+    type: expr
+    expr: "{{ .node_as }}:{{ .name }}"
+nodeclass:
+  - name: router
+    params: [router_id, as, vni]
+    values:
+      vlan: "100"
+interfaceclass:
+  - name: default
+    params: [peer_desc]
+`
+	dotContent := `
+graph {
+  r1 [xlabel="router"];
+  r2 [xlabel="router"];
+  r1 -- r2;
+}
+`
+	nm, err := buildTestModel(t, configYAML, dotContent, nil)
+	if err != nil {
+		t.Fatalf("Failed to build network model: %v", err)
+	}
+	checkNodeParam(t, nm, "r1", "as", "65001")
+	checkNodeParam(t, nm, "r2", "as", "65002")
+	checkNodeParam(t, nm, "r2", "vni", "VNI10100")
+
+	// computed values of nodes are also available in interfaces
+	r2, _ := nm.NodeByName("r2")
+	iface := r2.Interfaces[0]
+	if val, err := iface.GetParamValue("peer_desc"); err != nil || val != "65002:"+iface.Name {
+		t.Errorf("peer_desc of %s mismatch: %v, %v", iface.StringForMessage(), val, err)
+	}
+
+	t.Run("Missing_Param", func(t *testing.T) {
+		_, err := buildTestModel(t, strings.Replace(configYAML, ".vlan", ".vlan_id", 1), dotContent, nil)
+		if err == nil || !strings.Contains(err.Error(), "failed to compute vni for node:r1") {
+			t.Errorf("Expected missing parameter error, got: %v", err)
+		}
+	})
+
+	t.Run("Invalid_Expr", func(t *testing.T) {
+		_, err := buildTestModel(t, strings.Replace(configYAML, "{{ add 65000 .router_id }}", "{{ add 65000 .router_id", 1), dotContent, nil)
+		if err == nil || !strings.Contains(err.Error(), "invalid expr") {
+			t.Errorf("Expected invalid expr error, got: %v", err)
+		}
+	})
+}
+
+func TestObjectsSource(t *testing.T) {
+	configYAML := `
+name: objects_source_test
+global:
+  path: local
+layer:
+  - name: ip
+    default_connect: true
+    policy:
+      - name: ip
+        range: 10.0.0.0/16
+        prefix: 24
+      - name: lo
+        type: loopback
+        range: 10.255.0.0/24
+param_rule:
+  - name: ibgp
+    mode: attach
+    sort: desc
+    source:
+      type: objects
+      object: node
+      class: router
+      exclude_self: true
+      filter: "{{ if .ip_loopback }}true{{ end }}"
+    param_format:
+      peer: "{{ .obj_ip_loopback }}"
+      desc: "{{ .owner.name }}-{{ .value }}"
+  - name: adj
+    mode: attach
+    source:
+      type: objects
+      object: interface
+      neighbor_layer: ip
+      param_prefix: "peer_"
+nodeclass:
+  - name: router
+    policy: [lo]
+    params: [ibgp, adj]
+  - name: host
+interfaceclass:
+  - name: default
+    policy: [ip]
+`
+	dotContent := `
+graph {
+  r1 [xlabel="router"];
+  r2 [xlabel="router"];
+  r3 [xlabel="router"];
+  h1 [xlabel="host"];
+  r1 -- r2;
+  r2 -- r3;
+  r3 -- h1;
+}
+`
+	nm, err := buildTestModel(t, configYAML, dotContent, nil)
+	if err != nil {
+		t.Fatalf("Failed to build network model: %v", err)
+	}
+
+	valueParams := func(node string, rule string, key string) []string {
+		n, _ := nm.NodeByName(node)
+		ret := []string{}
+		for _, v := range n.GetValuesByParamRule(rule) {
+			val, err := v.GetParamValue(key)
+			if err != nil {
+				t.Errorf("%s of %s not found: %v", key, v.StringForMessage(), err)
+			}
+			ret = append(ret, val)
+		}
+		return ret
+	}
+	loopback := func(node string) string {
+		n, _ := nm.NodeByName(node)
+		val, _ := n.GetParamValue("ip_loopback")
+		return val
+	}
+
+	// other routers in the descending order
+	if peers := valueParams("r2", "ibgp", "peer"); !reflect.DeepEqual(peers, []string{loopback("r3"), loopback("r1")}) {
+		t.Errorf("ibgp peers of r2 mismatch: %v", peers)
+	}
+	if descs := valueParams("r1", "ibgp", "desc"); !reflect.DeepEqual(descs, []string{"r1-r3", "r1-r2"}) {
+		t.Errorf("ibgp descs of r1 mismatch: %v", descs)
+	}
+	// adjacent interfaces in the layer
+	if adj := valueParams("r3", "adj", "value"); !reflect.DeepEqual(adj, []string{"h1:net0", "r2:net1"}) {
+		t.Errorf("adjacent interfaces of r3 mismatch: %v", adj)
+	}
+	if addrs := valueParams("r1", "adj", "peer_ip_addr"); len(addrs) != 1 || !strings.HasPrefix(addrs[0], "10.0.") {
+		t.Errorf("adjacent addresses of r1 mismatch: %v", addrs)
+	}
+
+	t.Run("Invalid_Object", func(t *testing.T) {
+		_, err := buildTestModel(t, strings.Replace(configYAML, "object: node", "object: nodes", 1), dotContent, nil)
+		if err == nil || !strings.Contains(err.Error(), `unknown object type "nodes"`) {
+			t.Errorf("Expected invalid object error, got: %v", err)
+		}
+	})
+}
+
+func TestIPAMLock(t *testing.T) {
+	tmpDir := t.TempDir()
+	lockFile := filepath.Join(tmpDir, "ipam.lock")
+	configYAML := `
+name: ipam_lock_test
+global:
+  ipam_lock: ` + lockFile + `
+layer:
+  - name: ip
+    default_connect: true
+    policy:
+      - name: ip
+        range: 10.0.0.0/16
+        prefix: 30
+      - name: lo
+        type: loopback
+        range: 10.255.0.0/24
+nodeclass:
+  - name: default
+    policy: [lo]
+interfaceclass:
+  - name: default
+    policy: [ip]
+`
+	build := func(dotContent string, save bool) *types.NetworkModel {
+		t.Helper()
+		configFile := filepath.Join(tmpDir, "test.yaml")
+		dotFile := filepath.Join(tmpDir, "test.dot")
+		if err := os.WriteFile(configFile, []byte(configYAML), 0644); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+		if err := os.WriteFile(dotFile, []byte(dotContent), 0644); err != nil {
+			t.Fatalf("Failed to write dot file: %v", err)
+		}
+		cfg, err := types.LoadConfig(configFile)
+		if err != nil {
+			t.Fatalf("Failed to load config: %v", err)
+		}
+		nd, err := model.DiagramFromDotFile(dotFile)
+		if err != nil {
+			t.Fatalf("Failed to load dot file: %v", err)
+		}
+		nm, err := model.BuildNetworkModel(cfg, nd, false)
+		if err != nil {
+			t.Fatalf("Failed to build network model: %v", err)
+		}
+		if save {
+			if err := model.SaveIPAMLock(cfg, nm); err != nil {
+				t.Fatalf("Failed to save IPAM lock: %v", err)
+			}
+		}
+		return nm
+	}
+	addrs := func(nm *types.NetworkModel) map[string]string {
+		ret := map[string]string{}
+		for _, node := range nm.Nodes {
+			if val, err := node.GetParamValue("ip_loopback"); err == nil {
+				ret[node.Name] = val
+			}
+			for _, iface := range node.Interfaces {
+				// interface names are automatically assigned, so identified by the opposite node
+				if val, err := iface.GetParamValue("ip_addr"); err == nil {
+					ret[node.Name+"->"+iface.Opposite.Node.Name] = val
+				}
+			}
+		}
+		return ret
+	}
+
+	before := addrs(build(`
+graph {
+  r1 -- r2;
+  r2 -- r3;
+}
+`, true))
+
+	// a0 and its link are placed first, which shifts assignment without the lock
+	edited := `
+graph {
+  a0 -- r1;
+  r1 -- r2;
+  r2 -- r3;
+}
+`
+	after := addrs(build(edited, true))
+	for k, v := range before {
+		if after[k] != v {
+			t.Errorf("address of %s renumbered: %s -> %s", k, v, after[k])
+		}
+	}
+	used := map[string]string{}
+	for k, v := range after {
+		if other, ok := used[v]; ok {
+			t.Errorf("duplicated address %s for %s and %s", v, other, k)
+		}
+		used[v] = k
+	}
+	if _, ok := after["a0"]; !ok {
+		t.Errorf("loopback of new node a0 not assigned")
+	}
+
+	// the lock file is rewritten with the current objects, so the result is stable
+	again := addrs(build(edited, false))
+	if !reflect.DeepEqual(after, again) {
+		t.Errorf("addresses changed on rebuild: %v -> %v", after, again)
+	}
+
+	// removed objects are released from the lock file
+	build(`
+graph {
+  r1 -- r2;
+}
+`, true)
+	lock, err := os.ReadFile(lockFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(lock), "r3") {
+		t.Errorf("removed node r3 remains in the lock file:\n%s", lock)
+	}
+
+	// reversing the edge keeps the addresses by the lock, which differ from the order of a new assignment
+	reversed := addrs(build(`
+graph {
+  r2 -- r1;
+}
+`, true))
+	// links inserted before r1--r2 shift the automatic interface names on both ends
+	inserted := addrs(build(`
+graph {
+  a0 -- r1;
+  a1 -- r2;
+  r2 -- r1;
+}
+`, true))
+	for _, k := range []string{"r1->r2", "r2->r1"} {
+		if inserted[k] != reversed[k] {
+			t.Errorf("address of %s renumbered after inserting links: %s -> %s", k, reversed[k], inserted[k])
+		}
+	}
+}
+
+func TestGroupAddressPools(t *testing.T) {
+	configYAML := `
+name: group_pool_test
+global:
+  path: local
+podtemplate:
+  - name: pod
+    subgraph: cluster_pod
+    count: 2
+    groupclass: [podgroup]
+    attach:
+      uplink: ["spine1"]
+groupclass:
+  - name: podgroup
+layer:
+  - name: ip
+    default_connect: true
+    policy:
+      - name: ip
+        range: 10.0.0.0/16
+        prefix: 30
+        group_prefix: 20
+        group_class: podgroup
+      - name: lo
+        type: loopback
+        range: 10.255.0.0/16
+        group_prefix: 24
+nodeclass:
+  - name: default
+    policy: [lo]
+interfaceclass:
+  - name: default
+    policy: [ip]
+`
+	dotContent := `
+digraph {
+  spine1;
+  subgraph cluster_pod {
+    leaf1 -> leaf2;
+    leaf1 -> uplink;
+    leaf2 -> uplink;
+  }
+}
+`
+	nm, err := buildTestModel(t, configYAML, dotContent, nil)
+	if err != nil {
+		t.Fatalf("Failed to build network model: %v", err)
+	}
+
+	inRange := func(val string, prefix string) bool {
+		addr, err := netip.ParseAddr(val)
+		if err != nil {
+			t.Errorf("invalid address %s: %v", val, err)
+			return false
+		}
+		return netip.MustParsePrefix(prefix).Contains(addr)
+	}
+
+	// sub-ranges in the order of group names
+	for group, expected := range map[string]string{"pod1": "10.0.0.0/20", "pod2": "10.0.16.0/20"} {
+		g, ok := nm.GroupByName(group)
+		if !ok {
+			t.Fatalf("group %s not found", group)
+		}
+		if val, err := g.GetParamValue("ip_range"); err != nil || val != expected {
+			t.Errorf("ip_range of %s mismatch: expected %s, got %s (%v)", group, expected, val, err)
+		}
+	}
+	n, _ := nm.NodeByName("pod2_leaf1")
+	if val := n.GetRelativeParams()["group_ip_range"]; val != "10.0.16.0/20" {
+		t.Errorf("group_ip_range of pod2_leaf1 mismatch: %s", val)
+	}
+
+	loRanges := map[string]string{
+		"pod1_leaf1": "10.255.0.0/24", "pod1_leaf2": "10.255.0.0/24",
+		"pod2_leaf1": "10.255.1.0/24", "pod2_leaf2": "10.255.1.0/24",
+		"spine1": "10.255.2.0/24", // out of the groups
+	}
+	for name, prefix := range loRanges {
+		n, _ := nm.NodeByName(name)
+		val, _ := n.GetParamValue("ip_loopback")
+		if !inRange(val, prefix) {
+			t.Errorf("loopback %s of %s is out of %s", val, name, prefix)
+		}
+	}
+
+	for _, node := range nm.Nodes {
+		for _, iface := range node.Interfaces {
+			val, err := iface.GetParamValue("ip_addr")
+			if err != nil {
+				t.Errorf("ip_addr of %s not found", iface)
+				continue
+			}
+			// links in a pod are allocated from the pod sub-range, and uplinks from the following sub-range
+			expected := "10.0.32.0/20"
+			if iface.Node.Groups != nil && iface.Opposite.Node.Groups != nil {
+				expected = map[string]string{"pod1": "10.0.0.0/20", "pod2": "10.0.16.0/20"}[iface.Node.Groups[0].Name]
+			}
+			if !inRange(val, expected) {
+				t.Errorf("ip_addr %s of %s is out of %s", val, iface, expected)
+			}
+		}
+	}
+
+	t.Run("Nested_Groups_In_Pods", func(t *testing.T) {
+		nested := `
+digraph {
+  spine1;
+  subgraph cluster_pod {
+    subgraph cluster_rack { leaf1; }
+    leaf1 -> leaf2;
+    leaf1 -> uplink;
+    leaf2 -> uplink;
+  }
+}
+`
+		nm, err := buildTestModel(t, configYAML, nested, nil)
+		if err != nil {
+			t.Fatalf("Failed to build network model: %v", err)
+		}
+		// loopbacks in the innermost groups (in the order of group names), links in the pods (group_class)
+		for name, prefix := range map[string]string{
+			"pod1_leaf1": "10.255.1.0/24", "pod1_leaf2": "10.255.0.0/24",
+			"pod2_leaf1": "10.255.3.0/24", "pod2_leaf2": "10.255.2.0/24",
+			"spine1": "10.255.4.0/24",
+		} {
+			n, _ := nm.NodeByName(name)
+			val, _ := n.GetParamValue("ip_loopback")
+			if !inRange(val, prefix) {
+				t.Errorf("loopback %s of %s is out of %s", val, name, prefix)
+			}
+		}
+		n, _ := nm.NodeByName("pod2_leaf1")
+		for _, iface := range n.Interfaces {
+			if iface.Opposite.Node.Name != "pod2_leaf2" {
+				continue
+			}
+			if val, _ := iface.GetParamValue("ip_addr"); !inRange(val, "10.0.16.0/20") {
+				t.Errorf("ip_addr %s of %s is out of the pod2 sub-range", val, iface)
+			}
+		}
+	})
+
+	t.Run("Invalid_Group_Prefix", func(t *testing.T) {
+		invalid := strings.Replace(configYAML, "group_prefix: 20", "group_prefix: 31", 1)
+		_, err := buildTestModel(t, invalid, dotContent, nil)
+		if err == nil || !strings.Contains(err.Error(), "must not be longer than the prefix") {
+			t.Errorf("Expected group_prefix error, got: %v", err)
+		}
+	})
+}
+
+func TestIPAMReport(t *testing.T) {
+	configYAML := `
+name: ipam_report_test
+mgmt_layer:
+  name: mgmt
+  range: 172.20.0.0/24
+layer:
+  - name: ipv4
+    default_connect: true
+    policy:
+      - name: ipv4
+        range: 10.0.0.0/16
+        prefix: 30
+      - name: lo4
+        type: loopback
+        range: 10.255.0.0/24
+  - name: ipv6
+    default_connect: true
+    policy:
+      - name: ipv6
+        range: 2001:db8::/48
+        prefix: 64
+nodeclass:
+  - name: default
+    policy: [lo4]
+    mgmt_interfaceclass: mgmt
+interfaceclass:
+  - name: default
+    policy: [ipv4, ipv6]
+  - name: mgmt
+`
+	dotContent := `
+graph {
+  r1:eth0 -- r2:Ethernet1_1;
+}
+`
+	cfg, nm, err := buildTestConfigModel(t, configYAML, dotContent, nil)
+	if err != nil {
+		t.Fatalf("Failed to build network model: %v", err)
+	}
+
+	records, err := visual.GetIPAMRecords(cfg, nm, "", "")
+	if err != nil {
+		t.Fatalf("Failed to get IPAM records: %v", err)
+	}
+	csvData, err := visual.IPAMRecordsToCSV(records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"layer,type,node,interface,segment,address,prefix,name",
+		"mgmt,management,r1,mgmt,,172.20.0.2,172.20.0.0/24,r1.mgmt.lab.",
+		"ipv4,loopback,r1,,,10.255.0.1,10.255.0.1/32,r1.ipv4.lab.",
+		"ipv4,interface,r1,eth0,seg0,10.0.0.1,10.0.0.0/30,r1-eth0.ipv4.lab.",
+		"ipv4,interface,r2,Ethernet1_1,seg0,10.0.0.2,10.0.0.0/30,r2-ethernet1-1.ipv4.lab.",
+		"ipv4,segment,,,seg0,,10.0.0.0/30,",
+		"ipv6,interface,r1,eth0,seg1,2001:db8::1,2001:db8::/64,r1-eth0.ipv6.lab.",
+	} {
+		if !strings.Contains(string(csvData), line+"\n") {
+			t.Errorf("CSV line %q not found in:\n%s", line, csvData)
+		}
+	}
+
+	if _, err := visual.GetIPAMRecords(cfg, nm, "ipv5", ""); err == nil {
+		t.Errorf("unknown layer not detected")
+	}
+	v6records, err := visual.GetIPAMRecords(cfg, nm, "ipv6", "example.net")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hosts := string(visual.IPAMRecordsToHosts(v6records, "example.net"))
+	if hosts != "2001:db8::1\tr1-eth0.ipv6.example.net r1-eth0.ipv6\n2001:db8::2\tr2-ethernet1-1.ipv6.example.net r2-ethernet1-1.ipv6\n" {
+		t.Errorf("hosts mismatch:\n%s", hosts)
+	}
+
+	zones, err := visual.IPAMRecordsToZones(records, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for file, line := range map[string]string{
+		"db.lab":          "r1-eth0.ipv4\tIN\tA\t10.0.0.1\n",
+		"db.in-addr.arpa": "1.0.255.10.in-addr.arpa.\tIN\tPTR\tr1.ipv4.lab.\n",
+		"db.ip6.arpa":     "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa.\tIN\tPTR\tr1-eth0.ipv6.lab.\n",
+	} {
+		if !strings.Contains(string(zones[file]), line) {
+			t.Errorf("%q not found in zone %s:\n%s", line, file, zones[file])
+		}
+	}
+	if !strings.Contains(string(zones["db.lab"]), "r2-ethernet1-1.ipv6\tIN\tAAAA\t2001:db8::2\n") {
+		t.Errorf("AAAA record not found:\n%s", zones["db.lab"])
+	}
+
+	for name, dot := range map[string]string{
+		// loopback of r1-eth0 and interface eth0 of r1
+		"duplicated DNS name r1-eth0.ipv4.lab.": "graph {\n  r1:eth0 -- \"r1-eth0\";\n}\n",
+		// eth0/1 and eth0_1 of r1
+		"duplicated DNS name r1-eth0-1.ipv4.lab.": "graph {\n  r1:\"eth0/1\" -- r2;\n  r1:eth0_1 -- r3;\n}\n",
+		"cannot be converted into a DNS label":    "graph {\n  r1 -- \"__\";\n}\n",
+		// interface eth0 of a 60-letter node name
+		"is longer than 63 octets": "graph {\n  r1 -- " + strings.Repeat("a", 60) + ";\n}\n",
+	} {
+		cfg, nm, err := buildTestConfigModel(t, configYAML, dot, nil)
+		if err != nil {
+			t.Fatalf("Failed to build network model: %v", err)
+		}
+		if _, err := visual.GetIPAMRecords(cfg, nm, "", ""); err == nil || !strings.Contains(err.Error(), name) {
+			t.Errorf("Expected error %q, got: %v", name, err)
+		}
+	}
+}
+
+func TestSegmentVIP(t *testing.T) {
+	configYAML := `
+name: segment_vip_test
+layer:
+  - name: ip
+    default_connect: true
+    policy:
+      - name: lan
+        range: 10.0.0.0/16
+        prefix: 29
+        vip: last
+        vrid: 10
+      - name: gw
+        range: 10.1.0.0/16
+        prefix: 29
+        vip: first
+nodeclass:
+  - name: default
+interfaceclass:
+  - name: lan_if
+    policy: [lan]
+  - name: gw_if
+    policy: [gw]
+`
+	dotContent := `
+graph {
+  r1 -- r2 [taillabel="lan_if", headlabel="lan_if"];
+  r2 -- r3 [taillabel="gw_if", headlabel="gw_if"];
+}
+`
+	cfg, nm, err := buildTestConfigModel(t, configYAML, dotContent, nil)
+	if err != nil {
+		t.Fatalf("Failed to build network model: %v", err)
+	}
+
+	check := func(t *testing.T, nm *types.NetworkModel, node string, peer string, key string, expected string) {
+		t.Helper()
+		n, _ := nm.NodeByName(node)
+		for _, iface := range n.Interfaces {
+			if iface.Opposite.Node.Name != peer {
+				continue
+			}
+			if val, err := iface.GetParamValue(key); err != nil || val != expected {
+				t.Errorf("%s of %s mismatch: expected %s, got %s (%v)", key, iface, expected, val, err)
+			}
+		}
+	}
+	// the last host address is the VIP, and excluded from the interface addresses
+	check(t, nm, "r1", "r2", "ip_addr", "10.0.0.1")
+	check(t, nm, "r2", "r1", "ip_addr", "10.0.0.2")
+	check(t, nm, "r1", "r2", "ip_vip", "10.0.0.6")
+	check(t, nm, "r2", "r1", "ip_vip", "10.0.0.6")
+	check(t, nm, "r1", "r2", "ip_vrid", "10")
+	check(t, nm, "r1", "r2", "segment_vip", "10.0.0.6")
+	check(t, nm, "r1", "r2", "segment_vrid", "10")
+	// the first host address is the VIP (default vrid)
+	check(t, nm, "r2", "r3", "ip_addr", "10.1.0.2")
+	check(t, nm, "r3", "r2", "ip_addr", "10.1.0.3")
+	check(t, nm, "r3", "r2", "ip_vip", "10.1.0.1")
+	check(t, nm, "r3", "r2", "ip_vrid", "1")
+
+	records, err := visual.GetIPAMRecords(cfg, nm, "ip", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, r := range records {
+		if r.Type == visual.IPAMRecordVIP && r.Address == "10.0.0.6" && r.Prefix == "10.0.0.0/29" &&
+			r.Name == "seg0-vip.ip.lab." {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("VIP record not found in IPAM records")
+	}
+
+	t.Run("Conflict_With_Given_Address", func(t *testing.T) {
+		conflict := strings.Replace(dotContent, `r1 -- r2 [taillabel="lan_if", headlabel="lan_if"];`,
+			`r1 -- r2 [class="ip_net=10.0.0.0/29", taillabel="lan_if,ip_addr=10.0.0.6", headlabel="lan_if"];`, 1)
+		_, err := buildTestModel(t, configYAML, conflict, nil)
+		if err == nil || !strings.Contains(err.Error(), "conflicts with vip") {
+			t.Errorf("Expected vip conflict error, got: %v", err)
+		}
+	})
+
+	t.Run("Dual_Stack", func(t *testing.T) {
+		dualYAML := `
+name: segment_vip_test
+layer:
+  - name: ipv4
+    default_connect: true
+    policy:
+      - name: ipv4
+        range: 10.0.0.0/16
+        prefix: 29
+        vip: last
+        vrid: 10
+  - name: ipv6
+    default_connect: true
+    policy:
+      - name: ipv6
+        range: 2001:db8::/48
+        prefix: 64
+        vip: first
+        vrid: 20
+nodeclass:
+  - name: default
+interfaceclass:
+  - name: default
+    policy: [ipv4, ipv6]
+`
+		nm, err := buildTestModel(t, dualYAML, "graph {\n  r1 -- r2;\n}\n", nil)
+		if err != nil {
+			t.Fatalf("Failed to build network model: %v", err)
+		}
+		// VIPs of the layers are given separately
+		check(t, nm, "r1", "r2", "ipv4_vip", "10.0.0.6")
+		check(t, nm, "r1", "r2", "ipv4_vrid", "10")
+		check(t, nm, "r1", "r2", "ipv6_vip", "2001:db8::1")
+		check(t, nm, "r1", "r2", "ipv6_vrid", "20")
+		// segment_vip is of the first layer with vip
+		check(t, nm, "r1", "r2", "segment_vip", "10.0.0.6")
+		check(t, nm, "r1", "r2", "segment_vrid", "10")
+		check(t, nm, "r2", "r1", "ipv6_addr", "2001:db8::3")
+	})
+
+	t.Run("Invalid_VIP", func(t *testing.T) {
+		invalid := strings.Replace(configYAML, "vip: last", "vip: middle", 1)
+		_, err := buildTestModel(t, invalid, dotContent, nil)
+		if err == nil || !strings.Contains(err.Error(), "unknown vip") {
+			t.Errorf("Expected invalid vip error, got: %v", err)
+		}
+	})
+}
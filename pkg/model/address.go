@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math"
 	"net/netip"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -105,6 +106,10 @@ func (pool *ipPool) prefixToIndex(prefix netip.Prefix) (int, error) {
 }
 
 func (pool *ipPool) reserveAddr(addr netip.Addr) error {
+	if !pool.prefixRange.Contains(addr) {
+		// out of pool range
+		return nil
+	}
 	prefix, err := addr.Prefix(pool.bits)
 	if err != nil {
 		// out of pool range
@@ -156,6 +161,25 @@ func (pool *ipPool) reservePrefix(prefix netip.Prefix) error {
 	return nil
 }
 
+// reserveNetworkAddrs avoids the network address (and the broadcast address on IPv4) of the pool range.
+func (pool *ipPool) reserveNetworkAddrs() error {
+	err := pool.reserveAddr(pool.prefixRange.Addr())
+	if err != nil {
+		return err
+	}
+	if pool.prefixRange.Addr().Is4() {
+		baddr, err := pool.getitem(-1)
+		if err != nil {
+			return err
+		}
+		err = pool.reserveAddr(baddr.Addr())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (pool *ipPool) getAvailablePrefix(cnt int) ([]netip.Prefix, error) {
 	// Special case: -1 means get all available prefixes without capacity check
 	if cnt < 0 {
@@ -177,7 +201,7 @@ func (pool *ipPool) getAvailablePrefix(cnt int) ([]netip.Prefix, error) {
 		}
 		return prefixes, nil
 	}
-	
+
 	required := cnt + len(pool.boundIndex)
 	if !pool.isEnough(required) {
 		return nil, fmt.Errorf("no enough network prefix in address pool (%d required), cnt=%d, boundIndex=%d, availableBits=%d", required, cnt, len(pool.boundIndex), pool.availableBits)
@@ -207,8 +231,11 @@ type netSegment struct {
 	rifaces []*types.Interface // reserved interfaces (check consistency later)
 	raddrs  []netip.Addr       // reserved addresses for rifaces
 	bound   bool               // network address is bound (determined by reservation) or not
-	count   int                // number of unspecified interfaces for address assignment
-	bits    int                // default (automatically assigned) prefix length
+	locked  bool               // network address is determined by the IPAM lock file
+	vip     netip.Addr         // reserved address for gateway or virtual IP (invalid if not used)
+	segment *types.NetworkSegment
+	count   int // number of unspecified interfaces for address assignment
+	bits    int // default (automatically assigned) prefix length
 }
 
 func (seg *netSegment) String() string {
@@ -378,10 +405,10 @@ func searchSegments(nm *types.NetworkModel, layer *types.Layer, verbose bool) ([
 	return segs, nil
 }
 
-
 func setNeighbors(segs []*types.NetworkSegment, layer *types.Layer) {
 	for _, seg := range segs {
 		for _, iface := range seg.Interfaces {
+			iface.Segments[layer.Name] = seg
 			iface.Neighbors[layer.Name] = []*types.Neighbor{}
 			for _, n := range seg.Interfaces {
 				if iface != n {
@@ -446,6 +473,85 @@ func getIPAddrBlocks(poolrange netip.Prefix, bits int, cnt int) ([]netip.Prefix,
 	}
 }
 
+// ipPolicyGroupOf returns the group of the node for the sub-ranges of the policy,
+// or nil if group_prefix is not given or the node is out of the groups.
+func ipPolicyGroupOf(policy *types.IPPolicy, node *types.Node) *types.Group {
+	if policy.GroupPrefix == 0 {
+		return nil
+	}
+	// node.Groups are ordered from the innermost group
+	for _, group := range node.Groups {
+		if policy.GroupClass == "" || group.HasClass(policy.GroupClass) {
+			return group
+		}
+	}
+	return nil
+}
+
+// splitGroupPools carves sub-ranges of group_prefix from the pool, and splits the objects into them by groups.
+// Sub-ranges are given to groups in the order of group names, and the sub-range of objects out of the groups follows.
+// The sub-ranges are also given to the groups as parameters (<policy>_range).
+// If group_prefix is not given, the pool and all objects are returned as is.
+func splitGroupPools[T any](policy *types.IPPolicy, pool *ipPool, objs []T, groupOf func(T) *types.Group) ([]*ipPool, [][]T, error) {
+	if policy.GroupPrefix == 0 {
+		return []*ipPool{pool}, [][]T{objs}, nil
+	}
+
+	members := map[*types.Group][]T{}
+	for _, obj := range objs {
+		group := groupOf(obj)
+		members[group] = append(members[group], obj)
+	}
+	groups := make([]*types.Group, 0, len(members))
+	for group := range members {
+		if group != nil {
+			groups = append(groups, group)
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+	if _, ok := members[nil]; ok {
+		groups = append(groups, nil)
+	}
+
+	blockPool, err := initIPPool(pool.prefixRange, policy.GroupPrefix)
+	if err != nil {
+		return nil, nil, err
+	}
+	blocks, err := blockPool.getAvailablePrefix(len(groups))
+	if err != nil {
+		return nil, nil, fmt.Errorf("no enough group sub-ranges in policy %s: %w", policy.Name, err)
+	}
+
+	pools := make([]*ipPool, 0, len(groups))
+	lists := make([][]T, 0, len(groups))
+	for i, group := range groups {
+		subpool, err := initIPPool(blocks[i], pool.bits)
+		if err != nil {
+			return nil, nil, err
+		}
+		if group != nil {
+			group.AddParam(policy.GroupRangeReplacer(), blocks[i].String())
+		}
+		pools = append(pools, subpool)
+		lists = append(lists, members[group])
+	}
+	return pools, lists, nil
+}
+
+// segmentGroupOf returns the group of the segment for the sub-ranges of the policy,
+// or nil if the member nodes are not in the same group.
+func segmentGroupOf(policy *types.IPPolicy, seg *netSegment) *types.Group {
+	var ret *types.Group
+	for i, iface := range seg.Interfaces() {
+		group := ipPolicyGroupOf(policy, iface.Node)
+		if group == nil || (i > 0 && group != ret) {
+			return nil
+		}
+		ret = group
+	}
+	return ret
+}
+
 func searchIPLoopbacks(nm *types.NetworkModel, pool *ipPool, layer *types.Layer) ([]*types.Node, int, error) {
 	// search ip loopbacks
 	allLoopbacks := []*types.Node{}
@@ -469,8 +575,7 @@ func searchIPLoopbacks(nm *types.NetworkModel, pool *ipPool, layer *types.Layer)
 	return allLoopbacks, cnt, nil
 }
 
-func assignIPLoopbacks(nm *types.NetworkModel, layer *types.Layer) error {
-	poolmap := map[string]*ipPool{}
+func assignIPLoopbacks(nm *types.NetworkModel, layer *types.Layer, lock *IPAMLock) error {
 	for _, policy := range layer.LoopbackPolicy {
 		poolrange, err := netip.ParsePrefix(policy.AddrRange)
 		if err != nil {
@@ -481,41 +586,53 @@ func assignIPLoopbacks(nm *types.NetworkModel, layer *types.Layer) error {
 		if err != nil {
 			return err
 		}
-		poolmap[policy.Name] = pool
-	}
-	if len(poolmap) == 0 {
-		return nil
-	}
-
-	for _, pool := range poolmap {
-		// avoid network address
-		err := pool.reserveAddr(pool.prefixRange.Addr())
+		err = pool.reserveNetworkAddrs()
 		if err != nil {
 			return err
 		}
-		// avoid broadcast address on IPv4
-		if pool.prefixRange.Addr().Is4() {
-			baddr, err := pool.getitem(-1)
-			if err != nil {
-				return err
-			}
-			err = pool.reserveAddr(baddr.Addr())
-			if err != nil {
-				return err
-			}
-		}
 
-		allLoopbacks, cnt, err := searchIPLoopbacks(nm, pool, layer)
+		allLoopbacks, _, err := searchIPLoopbacks(nm, pool, layer)
 		if err != nil {
 			return err
 		}
-		prefixes, err := pool.getAvailablePrefix(cnt)
+
+		// split loopbacks into sub-ranges of groups (if group_prefix is given)
+		pools, members, err := splitGroupPools(policy, pool, allLoopbacks,
+			func(node *types.Node) *types.Group { return ipPolicyGroupOf(policy, node) },
+		)
 		if err != nil {
 			return err
 		}
-		for i, node := range allLoopbacks {
-			addr := prefixes[i].Addr()
-			node.AddParam(layer.IPLoopbackReplacer(), addr.String())
+		for i, subpool := range pools {
+			loopbacks := members[i]
+			if subpool != pool {
+				err = subpool.reserveNetworkAddrs()
+				if err != nil {
+					return err
+				}
+				// reserve given loopback addresses in the sub-range
+				_, _, err = searchIPLoopbacks(nm, subpool, layer)
+				if err != nil {
+					return err
+				}
+			}
+
+			// reuse loopback addresses in the lock file
+			loopbacks, err = reuseLockedAddrs(subpool, loopbacks,
+				func(node *types.Node) (netip.Addr, bool) { return lock.loopback(layer.Name, node.Name) },
+				func(node *types.Node, addr netip.Addr) { node.AddParam(layer.IPLoopbackReplacer(), addr.String()) },
+			)
+			if err != nil {
+				return err
+			}
+			prefixes, err := subpool.getAvailablePrefix(len(loopbacks))
+			if err != nil {
+				return err
+			}
+			for i, node := range loopbacks {
+				addr := prefixes[i].Addr()
+				node.AddParam(layer.IPLoopbackReplacer(), addr.String())
+			}
 		}
 	}
 
@@ -542,7 +659,7 @@ func searchManagementInterfaces(nm *types.NetworkModel, pool *ipPool, layer *typ
 	return allInterfaces, cnt, nil
 }
 
-func assignManagementIPAddresses(cfg *types.Config, nm *types.NetworkModel) error {
+func assignManagementIPAddresses(cfg *types.Config, nm *types.NetworkModel, lock *IPAMLock) error {
 	mlayer := &cfg.ManagementLayer
 	poolrange, err := netip.ParsePrefix(mlayer.AddrRange)
 	if err != nil {
@@ -587,25 +704,35 @@ func assignManagementIPAddresses(cfg *types.Config, nm *types.NetworkModel) erro
 		return err
 	}
 
-	allInterfaces, cnt, err := searchManagementInterfaces(nm, pool, mlayer)
+	allInterfaces, _, err := searchManagementInterfaces(nm, pool, mlayer)
+	if err != nil {
+		return err
+	}
+	setManagementAddr := func(iface *types.Interface, addr netip.Addr) {
+		iface.AddParam(mlayer.IPAddressReplacer(), addr.String())
+		iface.AddParam(mlayer.IPNetworkReplacer(), poolrange.String())
+		iface.AddParam(mlayer.IPPrefixLengthReplacer(), strconv.Itoa(poolrange.Bits()))
+	}
+	// reuse management addresses in the lock file
+	allInterfaces, err = reuseLockedAddrs(pool, allInterfaces,
+		func(iface *types.Interface) (netip.Addr, bool) { return lock.management(iface.Node.Name) },
+		setManagementAddr,
+	)
 	if err != nil {
 		return err
 	}
-	prefixes, err := pool.getAvailablePrefix(cnt)
+	prefixes, err := pool.getAvailablePrefix(len(allInterfaces))
 	if err != nil {
 		return err
 	}
 	for i, iface := range allInterfaces {
-		addr := prefixes[i].Addr()
-		iface.AddParam(mlayer.IPAddressReplacer(), addr.String())
-		iface.AddParam(mlayer.IPNetworkReplacer(), poolrange.String())
-		iface.AddParam(mlayer.IPPrefixLengthReplacer(), strconv.Itoa(poolrange.Bits()))
+		setManagementAddr(iface, prefixes[i].Addr())
 	}
 
 	return nil
 }
 
-func assignIPAddresses(nm *types.NetworkModel, layer *types.Layer) error {
+func assignIPAddresses(nm *types.NetworkModel, layer *types.Layer, lock *IPAMLock) error {
 	poolmap := map[string]*ipPool{}
 	for _, policy := range layer.IPPolicy {
 		poolrange, err := netip.ParsePrefix(policy.AddrRange)
@@ -655,7 +782,7 @@ func assignIPAddresses(nm *types.NetworkModel, layer *types.Layer) error {
 		}
 
 		// check segment members
-		netSegment := &netSegment{bits: pool.bits}
+		netSegment := &netSegment{bits: pool.bits, segment: seg}
 		for _, conn := range seg.Connections {
 			netSegment.checkConnection(conn, layer)
 		}
@@ -663,9 +790,6 @@ func assignIPAddresses(nm *types.NetworkModel, layer *types.Layer) error {
 			netSegment.checkInterface(iface, layer)
 		}
 		pool.segments = append(pool.segments, netSegment)
-		if !netSegment.bound {
-			pool.n_unassigned += 1
-		}
 
 		// check address reservation consistency
 		err := netSegment.checkReservedInterfaces()
@@ -674,29 +798,85 @@ func assignIPAddresses(nm *types.NetworkModel, layer *types.Layer) error {
 		}
 	}
 
-	for policy, pool := range poolmap {
+	// split segments into sub-ranges of groups (if group_prefix is given)
+	pools := make([]*ipPool, 0, len(poolmap))
+	poolPolicy := map[*ipPool]*types.IPPolicy{}
+	for _, policy := range layer.IPPolicy {
+		pool := poolmap[policy.Name]
+		subpools, members, err := splitGroupPools(policy, pool, pool.segments,
+			func(seg *netSegment) *types.Group { return segmentGroupOf(policy, seg) },
+		)
+		if err != nil {
+			return err
+		}
+		for i, subpool := range subpools {
+			subpool.segments = members[i]
+			pools = append(pools, subpool)
+			poolPolicy[subpool] = policy
+		}
+	}
+
+	// reuse segment prefixes in the lock file
+	segIfaces := make([][]*types.Interface, 0, len(segs))
+	for _, seg := range segs {
+		segIfaces = append(segIfaces, seg.Interfaces)
+	}
+	lockKeys := interfaceLockKeys(segIfaces)
+	reuseLockedPrefixes(pools, layer, lock, lockKeys)
+	for _, pool := range pools {
+		for _, seg := range pool.segments {
+			if !seg.bound && !seg.locked {
+				pool.n_unassigned += 1
+			}
+		}
+	}
+
+	for _, pool := range pools {
+		policy := poolPolicy[pool]
 		prefixes, err := pool.getAvailablePrefix(pool.n_unassigned)
 		if err != nil {
 			return err
 		}
 		for _, seg := range pool.segments {
-			if !seg.bound {
+			if !seg.bound && !seg.locked {
 				if len(prefixes) <= 0 {
-					return fmt.Errorf("address reservation panic in policy %v", policy)
+					return fmt.Errorf("address reservation panic in policy %v", policy.Name)
 				}
 				// pop prefixes
 				seg.prefix = prefixes[0]
 				prefixes = prefixes[1:]
 			}
-			addrs, err := getIPAddr(seg.prefix, len(seg.uifaces), seg.raddrs)
-			if err != nil {
-				return err
+			p2p := policy.PointToPoint
+			if policy.VIP != "" {
+				err = seg.reserveVIP(layer, policy)
+				if err != nil {
+					return err
+				}
 			}
-			for i, iface := range seg.uifaces {
-				iface.AddParam(layer.IPAddressReplacer(), addrs[i].String())
+			uifaces, laddrs := seg.reuseLockedAddrs(layer, lock, lockKeys, p2p)
+			for iface, addr := range laddrs {
+				iface.AddParam(layer.IPAddressReplacer(), addr.String())
 				iface.AddParam(layer.IPNetworkReplacer(), seg.prefix.String())
 				iface.AddParam(layer.IPPrefixLengthReplacer(), strconv.Itoa(seg.prefix.Bits()))
 			}
+			if len(uifaces) > 0 {
+				reserved := append([]netip.Addr{}, seg.raddrs...)
+				if seg.vip.IsValid() {
+					reserved = append(reserved, seg.vip)
+				}
+				for _, addr := range laddrs {
+					reserved = append(reserved, addr)
+				}
+				addrs, err := getIPAddr(seg.prefix, len(uifaces), reserved, p2p)
+				if err != nil {
+					return err
+				}
+				for i, iface := range uifaces {
+					iface.AddParam(layer.IPAddressReplacer(), addrs[i].String())
+					iface.AddParam(layer.IPNetworkReplacer(), seg.prefix.String())
+					iface.AddParam(layer.IPPrefixLengthReplacer(), strconv.Itoa(seg.prefix.Bits()))
+				}
+			}
 			for i, iface := range seg.rifaces {
 				iface.AddParam(layer.IPAddressReplacer(), seg.raddrs[i].String())
 				iface.AddParam(layer.IPNetworkReplacer(), seg.prefix.String())
@@ -710,12 +890,81 @@ func assignIPAddresses(nm *types.NetworkModel, layer *types.Layer) error {
 	return nil
 }
 
-func getIPAddr(pool netip.Prefix, cnt int, reserved []netip.Addr) ([]netip.Addr, error) {
+// lastAddrOf returns the last address of the prefix (i.e., the broadcast address on IPv4).
+func lastAddrOf(prefix netip.Prefix) netip.Addr {
+	slice := prefix.Masked().Addr().AsSlice()
+	hostBits := len(slice)*8 - prefix.Bits()
+	for i := len(slice) - 1; i >= 0 && hostBits > 0; i-- {
+		n := min(hostBits, 8)
+		slice[i] |= byte(1<<n - 1)
+		hostBits -= n
+	}
+	addr, _ := netip.AddrFromSlice(slice)
+	return addr
+}
+
+// getVIPAddr returns the first or last host address of the prefix for gateway or virtual IP.
+func getVIPAddr(prefix netip.Prefix, position string) (netip.Addr, error) {
+	if prefix.Addr().BitLen()-prefix.Bits() < 2 {
+		return netip.Addr{}, fmt.Errorf("prefix %s is too small for vip", prefix)
+	}
+	switch position {
+	case types.IPPolicyVIPFirst:
+		return prefix.Masked().Addr().Next(), nil
+	case types.IPPolicyVIPLast:
+		last := lastAddrOf(prefix)
+		if last.Is4() {
+			// avoid broadcast address
+			last = last.Prev()
+		}
+		return last, nil
+	default:
+		return netip.Addr{}, fmt.Errorf("unknown vip %s", position)
+	}
+}
+
+// reserveVIP reserves the gateway or virtual IP of the segment,
+// and gives it (and the VRRP group ID) to the segment and the member interfaces.
+// segment_vip and segment_vrid of interfaces are kept for the first layer with vip (e.g., IPv4 on dual stack).
+func (seg *netSegment) reserveVIP(layer *types.Layer, policy *types.IPPolicy) error {
+	vip, err := getVIPAddr(seg.prefix, policy.VIP)
+	if err != nil {
+		return err
+	}
+	for i, addr := range seg.raddrs {
+		if addr == vip {
+			return fmt.Errorf("given address %s of %s conflicts with vip of policy %s", addr, seg.rifaces[i], policy.Name)
+		}
+	}
+	seg.vip = vip
+	vrid := strconv.Itoa(policy.GetVRID())
+	if seg.segment != nil {
+		seg.segment.AddParam(types.SegmentVIPKey, vip.String())
+		seg.segment.AddParam(types.SegmentVRIDKey, vrid)
+	}
+	for _, iface := range seg.Interfaces() {
+		iface.AddParam(layer.IPVIPReplacer(), vip.String())
+		iface.AddParam(layer.IPVRIDReplacer(), vrid)
+		if !iface.HasParam(types.SegmentVIPParam) {
+			iface.AddParam(types.SegmentVIPParam, vip.String())
+			iface.AddParam(types.SegmentVRIDParam, vrid)
+		}
+	}
+	return nil
+}
+
+// getIPAddr returns cnt addresses in the pool except for reserved addresses.
+// If p2p is true and the pool is /31 or /127, both addresses are available (RFC 3021, RFC 6164).
+func getIPAddr(pool netip.Prefix, cnt int, reserved []netip.Addr, p2p bool) ([]netip.Addr, error) {
 	var potential int
 	err_too_small := fmt.Errorf("addr pool is too small")
+	p2p = p2p && types.IsPointToPointPrefix(pool)
 
 	// calculate number of addresses to generate
-	if pool.Addr().Is4() {
+	if p2p {
+		// point-to-point: use both addresses
+		potential = 2 - len(reserved)
+	} else if pool.Addr().Is4() {
 		// IPv4: skip network address and broadcast address
 		potential = int(math.Pow(2, float64(32-pool.Bits()))) - 2 - len(reserved)
 	} else {
@@ -725,6 +974,9 @@ func getIPAddr(pool netip.Prefix, cnt int, reserved []netip.Addr) ([]netip.Addr,
 	if cnt <= 0 {
 		cnt = potential
 	} else if cnt > potential {
+		if !p2p && types.IsPointToPointPrefix(pool) {
+			return nil, fmt.Errorf("addr pool %s is too small (use p2p: true in the policy for point-to-point segments)", pool)
+		}
 		return nil, err_too_small
 	}
 
@@ -736,6 +988,10 @@ func getIPAddr(pool netip.Prefix, cnt int, reserved []netip.Addr) ([]netip.Addr,
 	// generate addresses
 	var addrs = make([]netip.Addr, 0, cnt)
 	current_addr := pool.Addr()
+	if p2p {
+		// start from the first address of the pool
+		current_addr = current_addr.Prev()
+	}
 	for len(addrs) < cnt {
 		current_addr = current_addr.Next()
 		if !pool.Contains(current_addr) {
@@ -746,4 +1002,3 @@ func getIPAddr(pool netip.Prefix, cnt int, reserved []netip.Addr) ([]netip.Addr,
 	}
 	return addrs, nil
 }
-
@@ -116,7 +116,7 @@ func TestGetIPAddr(t *testing.T) {
 	empty := []netip.Addr{}
 	t.Run("ipv4", func(t *testing.T) {
 		prefix := netip.MustParsePrefix("192.0.2.16/28")
-		addrs, err := getIPAddr(prefix, 0, empty)
+		addrs, err := getIPAddr(prefix, 0, empty, false)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -129,7 +129,7 @@ func TestGetIPAddr(t *testing.T) {
 			t.Errorf("last addr mismatch %v", last)
 		}
 
-		addrs, err = getIPAddr(prefix, 9, empty)
+		addrs, err = getIPAddr(prefix, 9, empty, false)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -144,7 +144,7 @@ func TestGetIPAddr(t *testing.T) {
 
 	t.Run("ipv6", func(t *testing.T) {
 		prefix := netip.MustParsePrefix("2001:db8:1234:abcd:5678:fedc:1111:1120/123")
-		addrs, err := getIPAddr(prefix, 0, empty)
+		addrs, err := getIPAddr(prefix, 0, empty, false)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -157,7 +157,7 @@ func TestGetIPAddr(t *testing.T) {
 			t.Errorf("last addr mismatch %v", last)
 		}
 
-		addrs, err = getIPAddr(prefix, 6, empty)
+		addrs, err = getIPAddr(prefix, 6, empty, false)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -170,3 +170,60 @@ func TestGetIPAddr(t *testing.T) {
 		}
 	})
 }
+
+func TestGetIPAddr_PointToPoint(t *testing.T) {
+	empty := []netip.Addr{}
+	testCases := []struct {
+		prefix string
+		first  string
+		last   string
+	}{
+		{"192.0.2.10/31", "192.0.2.10", "192.0.2.11"},
+		{"2001:db8::a/127", "2001:db8::a", "2001:db8::b"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.prefix, func(t *testing.T) {
+			prefix := netip.MustParsePrefix(tc.prefix)
+			addrs, err := getIPAddr(prefix, 2, empty, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(addrs) != 2 || addrs[0].String() != tc.first || addrs[1].String() != tc.last {
+				t.Errorf("addrs mismatch %v", addrs)
+			}
+
+			if _, err = getIPAddr(prefix, 3, empty, true); err == nil {
+				t.Errorf("too many interfaces not detected")
+			}
+			if _, err = getIPAddr(prefix, 2, empty, false); err == nil {
+				t.Errorf("point-to-point prefix without p2p not detected")
+			}
+		})
+	}
+}
+
+func TestGetVIPAddr(t *testing.T) {
+	testCases := []struct {
+		prefix   string
+		position string
+		expected string
+	}{
+		{"192.0.2.0/24", "first", "192.0.2.1"},
+		{"192.0.2.0/24", "last", "192.0.2.254"},
+		{"192.0.2.8/29", "last", "192.0.2.14"},
+		{"2001:db8::/64", "first", "2001:db8::1"},
+		{"2001:db8::/64", "last", "2001:db8::ffff:ffff:ffff:ffff"},
+	}
+	for _, tc := range testCases {
+		vip, err := getVIPAddr(netip.MustParsePrefix(tc.prefix), tc.position)
+		if err != nil {
+			t.Errorf("%s %s: %v", tc.prefix, tc.position, err)
+		} else if vip.String() != tc.expected {
+			t.Errorf("%s %s: expected %s, got %s", tc.prefix, tc.position, tc.expected, vip)
+		}
+	}
+
+	if _, err := getVIPAddr(netip.MustParsePrefix("192.0.2.0/31"), "first"); err == nil {
+		t.Errorf("too small prefix not detected")
+	}
+}
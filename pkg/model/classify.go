@@ -0,0 +1,186 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/cpflat/dot2net/pkg/types"
+)
+
+// classifyObjects assigns class labels to objects based on the classify rules.
+// This must be called before checkClasses.
+func classifyObjects(cfg *types.Config, nm *types.NetworkModel) error {
+	for _, rule := range cfg.ClassifyRules {
+		var err error
+		switch rule.GetTarget() {
+		case types.ClassTypeNode:
+			err = classifyNodes(cfg, nm, rule)
+		case types.ClassTypeInterface:
+			err = classifyInterfaces(cfg, nm, rule)
+		case types.ClassTypeConnection:
+			err = classifyConnections(cfg, nm, rule)
+		default:
+			err = fmt.Errorf("invalid target %s", rule.Target)
+		}
+		if err != nil {
+			return fmt.Errorf("classify rule (class: %s): %w", rule.Class, err)
+		}
+	}
+	return nil
+}
+
+// hasAnyClass returns true if the object has any of the classes.
+// Empty classes are always satisfied.
+func hasAnyClass(lo types.LabelOwner, classes []string) bool {
+	if len(classes) == 0 {
+		return true
+	}
+	for _, cls := range classes {
+		if lo.HasClass(cls) {
+			return true
+		}
+	}
+	return false
+}
+
+// distanceFromRoot returns hop counts from the nearest nodes of the root class.
+// Nodes unreachable from the root nodes are not included.
+func distanceFromRoot(nm *types.NetworkModel, root string) map[*types.Node]int {
+	dist := map[*types.Node]int{}
+	queue := []*types.Node{}
+	for _, node := range nm.Nodes {
+		if node.HasClass(root) {
+			dist[node] = 0
+			queue = append(queue, node)
+		}
+	}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, iface := range node.Interfaces {
+			if iface.Opposite == nil {
+				continue
+			}
+			neighbor := iface.Opposite.Node
+			if _, ok := dist[neighbor]; !ok {
+				dist[neighbor] = dist[node] + 1
+				queue = append(queue, neighbor)
+			}
+		}
+	}
+	return dist
+}
+
+func classifyNodes(cfg *types.Config, nm *types.NetworkModel, rule *types.ClassifyRule) error {
+	var re *regexp.Regexp
+	if rule.Name != "" {
+		var err error
+		re, err = regexp.Compile(rule.Name)
+		if err != nil {
+			return err
+		}
+	}
+	var dist map[*types.Node]int
+	if rule.Root != "" {
+		dist = distanceFromRoot(nm, rule.Root)
+	}
+
+	// evaluate all nodes before assigning to keep the rule independent of the node order
+	targets := []*types.Node{}
+	for _, node := range nm.Nodes {
+		if re != nil && !re.MatchString(node.Name) {
+			continue
+		}
+		if rule.Group != "" {
+			found := false
+			for _, group := range node.Groups {
+				if group.Name == rule.Group || group.HasClass(rule.Group) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				continue
+			}
+		}
+		degree := len(node.Interfaces)
+		if degree < rule.MinDegree || (rule.MaxDegree != nil && degree > *rule.MaxDegree) {
+			continue
+		}
+		if len(rule.NeighborClasses) > 0 {
+			found := false
+			for _, iface := range node.Interfaces {
+				if iface.Opposite != nil && hasAnyClass(iface.Opposite.Node, rule.NeighborClasses) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				continue
+			}
+		}
+		if dist != nil {
+			if d, ok := dist[node]; !ok || d != rule.Distance {
+				continue
+			}
+		}
+		targets = append(targets, node)
+	}
+
+	for _, node := range targets {
+		if err := node.AddClass(cfg, rule.Class); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func classifyInterfaces(cfg *types.Config, nm *types.NetworkModel, rule *types.ClassifyRule) error {
+	var re *regexp.Regexp
+	if rule.Name != "" {
+		var err error
+		re, err = regexp.Compile(rule.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, node := range nm.Nodes {
+		for _, iface := range node.Interfaces {
+			if re != nil && iface.Name == "" {
+				// interfaces are automatically named after classification (with the classes)
+				return fmt.Errorf("name is available only for interfaces named in DOT (interface of %s with no port name)",
+					node.Name)
+			}
+			if re != nil && !re.MatchString(iface.Name) {
+				continue
+			}
+			if !hasAnyClass(node, rule.NodeClasses) {
+				continue
+			}
+			if len(rule.OppositeNodeClasses) > 0 &&
+				(iface.Opposite == nil || !hasAnyClass(iface.Opposite.Node, rule.OppositeNodeClasses)) {
+				continue
+			}
+			if err := iface.AddClass(cfg, rule.Class); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func classifyConnections(cfg *types.Config, nm *types.NetworkModel, rule *types.ClassifyRule) error {
+	for _, conn := range nm.Connections {
+		src := conn.Src.Node
+		dst := conn.Dst.Node
+		// endpoint predicates are satisfied in either direction
+		if (hasAnyClass(src, rule.NodeClasses) && hasAnyClass(dst, rule.OppositeNodeClasses)) ||
+			(hasAnyClass(dst, rule.NodeClasses) && hasAnyClass(src, rule.OppositeNodeClasses)) {
+			if err := conn.AddClass(cfg, rule.Class); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/cpflat/dot2net/pkg/types"
+)
+
+// TemplateObjectKey is the key of the structured object in the template data.
+const TemplateObjectKey = "Obj"
+
+// templateObject is a structured view of an object for config templates with structured: true.
+// It provides related objects as lists so that templates can range and filter over them,
+// e.g., {{ range .Obj.Interfaces }}{{ if .HasClass "uplink" }}{{ .Param "ipv4_addr" }}{{ end }}{{ end }}
+type templateObject struct {
+	ns types.NameSpacer
+}
+
+func newTemplateObject(ns types.NameSpacer) *templateObject {
+	return &templateObject{ns: ns}
+}
+
+func newTemplateObjects[T types.NameSpacer](objs []T) []*templateObject {
+	ret := make([]*templateObject, 0, len(objs))
+	for _, obj := range objs {
+		ret = append(ret, newTemplateObject(obj))
+	}
+	return ret
+}
+
+// configTemplateData returns the data given to the config template.
+// Structured config templates get the structured object in addition to the parameters.
+func configTemplateData(ns types.NameSpacer, ct *types.ConfigTemplate) interface{} {
+	params := ns.GetRelativeParams()
+	if !ct.Structured {
+		return params
+	}
+	data := make(map[string]interface{}, len(params)+1)
+	for k, v := range params {
+		data[k] = v
+	}
+	data[TemplateObjectKey] = newTemplateObject(ns)
+	return data
+}
+
+// Name returns the name of the object.
+func (o *templateObject) Name() string {
+	switch obj := o.ns.(type) {
+	case *types.NetworkModel:
+		return obj.Name
+	case *types.Node:
+		return obj.Name
+	case *types.Interface:
+		return obj.Name
+	case *types.Connection:
+		return obj.Name
+	case *types.Group:
+		return obj.Name
+	case *types.NetworkSegment:
+		return obj.Name
+	case *types.Neighbor:
+		return obj.Neighbor.Name
+	case *types.Value:
+		return fmt.Sprintf("%s[%d]", obj.ParamRuleName, obj.Index)
+	default:
+		return ""
+	}
+}
+
+// Params returns all parameters available in the object namespace.
+func (o *templateObject) Params() map[string]string {
+	if params := o.ns.GetRelativeParams(); len(params) > 0 {
+		return params
+	}
+	return o.ns.GetParams()
+}
+
+// Param returns the parameter value, or an error if the parameter does not exist.
+func (o *templateObject) Param(key string) (string, error) {
+	val, ok := o.Params()[key]
+	if !ok {
+		return "", fmt.Errorf("parameter %s not found in %s", key, o.ns.StringForMessage())
+	}
+	return val, nil
+}
+
+// HasParam returns true if the parameter exists.
+func (o *templateObject) HasParam(key string) bool {
+	_, ok := o.Params()[key]
+	return ok
+}
+
+// HasClass returns true if the object has the class.
+func (o *templateObject) HasClass(name string) bool {
+	if lo, ok := o.ns.(types.LabelOwner); ok {
+		return lo.HasClass(name)
+	}
+	return false
+}
+
+// Classes returns the class names of the object.
+func (o *templateObject) Classes() []string {
+	if lo, ok := o.ns.(types.LabelOwner); ok {
+		return lo.ClassLabels()
+	}
+	return nil
+}
+
+// Node returns the node of the interface or neighbor.
+func (o *templateObject) Node() *templateObject {
+	switch obj := o.ns.(type) {
+	case *types.Node:
+		return o
+	case *types.Interface:
+		return newTemplateObject(obj.Node)
+	case *types.Neighbor:
+		return newTemplateObject(obj.Neighbor.Node)
+	default:
+		return nil
+	}
+}
+
+// Opposite returns the opposite interface of the interface.
+func (o *templateObject) Opposite() *templateObject {
+	if iface, ok := o.ns.(*types.Interface); ok && iface.Opposite != nil {
+		return newTemplateObject(iface.Opposite)
+	}
+	return nil
+}
+
+// Nodes returns the nodes in the network or group.
+func (o *templateObject) Nodes() []*templateObject {
+	switch obj := o.ns.(type) {
+	case *types.NetworkModel:
+		return newTemplateObjects(obj.Nodes)
+	case *types.Group:
+		return newTemplateObjects(obj.Nodes)
+	default:
+		return nil
+	}
+}
+
+// Interfaces returns the interfaces of the node, connection or segment.
+func (o *templateObject) Interfaces() []*templateObject {
+	switch obj := o.ns.(type) {
+	case *types.Node:
+		return newTemplateObjects(obj.Interfaces)
+	case *types.Connection:
+		return newTemplateObjects([]*types.Interface{obj.Src, obj.Dst})
+	case *types.NetworkSegment:
+		return newTemplateObjects(obj.Interfaces)
+	default:
+		return nil
+	}
+}
+
+// Neighbors returns the neighbor interfaces in the network segment of the layer.
+// For a node, neighbors of all its interfaces are returned.
+func (o *templateObject) Neighbors(layer string) []*templateObject {
+	var ifaces []*types.Interface
+	switch obj := o.ns.(type) {
+	case *types.Node:
+		ifaces = obj.Interfaces
+	case *types.Interface:
+		ifaces = []*types.Interface{obj}
+	default:
+		return nil
+	}
+
+	ret := []*templateObject{}
+	for _, iface := range ifaces {
+		seg, ok := iface.Segments[layer]
+		if !ok {
+			continue
+		}
+		for _, n := range seg.Interfaces {
+			if n != iface {
+				ret = append(ret, newTemplateObject(n))
+			}
+		}
+	}
+	return ret
+}
+
+// Groups returns the groups of the node (or the node of the interface).
+func (o *templateObject) Groups() []*templateObject {
+	switch obj := o.ns.(type) {
+	case *types.Node:
+		return newTemplateObjects(obj.Groups)
+	case *types.Interface:
+		return newTemplateObjects(obj.Node.Groups)
+	default:
+		return nil
+	}
+}
+
+// Values returns the values attached to the object by the param_rule.
+func (o *templateObject) Values(rule string) []*templateObject {
+	if vo, ok := o.ns.(types.ValueOwner); ok {
+		return newTemplateObjects(vo.GetValuesByParamRule(rule))
+	}
+	return nil
+}
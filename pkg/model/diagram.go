@@ -1,20 +1,22 @@
 package model
 
 import (
+	"fmt"
 	"os"
-	"regexp"
 	"sort"
 	"strings"
 
 	"github.com/awalterschulze/gographviz"
 	mapset "github.com/deckarep/golang-set/v2"
-)
 
-var SEPARATOR *regexp.Regexp
+	"github.com/cpflat/dot2net/pkg/types"
+)
 
 type Diagram struct {
 	graph      *gographviz.Graph
 	nodeGroups map[string][]string
+
+	podExpanded bool // pod templates are already instantiated
 }
 
 func DiagramFromDotFile(filepath string) (*Diagram, error) {
@@ -22,13 +24,19 @@ func DiagramFromDotFile(filepath string) (*Diagram, error) {
 	if err != nil {
 		return nil, err
 	}
-	graphAst, _ := gographviz.Parse(src)
-	graph := gographviz.NewGraph()
+	graphAst, err := gographviz.Parse(quoteRangeTokens(src))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath, err)
+	}
+	graph := newLenientGraph()
 	if err := gographviz.Analyse(graphAst, graph); err != nil {
-		panic(err)
+		return nil, fmt.Errorf("failed to analyse %s: %w", filepath, err)
 	}
 
-	diagram := &Diagram{graph: graph, nodeGroups: map[string][]string{}}
+	diagram := &Diagram{graph: graph.Graph, nodeGroups: map[string][]string{}}
+	if err := diagram.expandShorthand(); err != nil {
+		return nil, fmt.Errorf("failed to expand %s: %w", filepath, err)
+	}
 	diagram.searchGroupMembers(graph.Name)
 	return diagram, nil
 }
@@ -253,15 +261,207 @@ func getSubGraphLabels(s *gographviz.SubGraph) (labels []string) {
 	return labels
 }
 
-func ParseLabels(value string) (classes []string) {
+// getDotAttrParams returns all DOT attributes as parameters with DotAttrPrefix.
+func getDotAttrParams(attrs gographviz.Attrs) map[string]string {
+	params := map[string]string{}
+	for k, v := range attrs {
+		params[types.DotAttrPrefix+trimQuote(string(k))] = trimQuote(v)
+	}
+	return params
+}
+
+// getEdgeDotAttrParams returns DOT attributes of an edge as parameters with DotAttrPrefix.
+// Interfaces have all the edge attributes, and head/tail attributes
+// are also given to dst/src interfaces without head/tail (e.g., tailbw -> dot_attr_bw of src interface).
+func getEdgeDotAttrParams(e *gographviz.Edge) (params map[string]string,
+	srcParams map[string]string, dstParams map[string]string) {
+	params = getDotAttrParams(e.Attrs)
+	srcParams = getDotAttrParams(e.Attrs)
+	dstParams = getDotAttrParams(e.Attrs)
+	for k, v := range e.Attrs {
+		key := trimQuote(string(k))
+		if strings.HasPrefix(key, "head") && len(key) > len("head") {
+			dstParams[types.DotAttrPrefix+strings.TrimPrefix(key, "head")] = trimQuote(v)
+		} else if strings.HasPrefix(key, "tail") && len(key) > len("tail") {
+			srcParams[types.DotAttrPrefix+strings.TrimPrefix(key, "tail")] = trimQuote(v)
+		}
+	}
+	return params, srcParams, dstParams
+}
+
+// label grammar symbols
+const (
+	labelSeparators = ",;"
+	labelQuotes     = "\"'"
+	labelEscape     = '\\'
+	labelListStart  = '['
+	labelListEnd    = ']'
+	labelSymbols    = labelSeparators + labelQuotes + "\\[]"
+)
+
+// ListValueSeparator joins items of a list value (e.g., k=[a, b] -> "a b").
+const ListValueSeparator = " "
+
+// unquoteDotString removes DOT-level quotes from an attribute value.
+// Merged attribute values (e.g., "a";"b" in MergeDiagram) are concatenated.
+func unquoteDotString(value string) string {
+	var b strings.Builder
+	i := 0
+	for i < len(value) {
+		if value[i] != '"' {
+			b.WriteByte(value[i])
+			i++
+			continue
+		}
+		// DOT quoted string: only \" is escaped in DOT
+		i++
+		for i < len(value) && value[i] != '"' {
+			if value[i] == '\\' && i+1 < len(value) && value[i+1] == '"' {
+				i++
+			}
+			b.WriteByte(value[i])
+			i++
+		}
+		i++ // closing quote
+	}
+	return b.String()
+}
+
+// labelSegment is a part of a label under parsing.
+// Quoted or escaped characters are kept in trimming spaces.
+type labelSegment struct {
+	runes []rune
+	kept  []bool
+}
+
+func (seg *labelSegment) write(c rune, kept bool) {
+	seg.runes = append(seg.runes, c)
+	seg.kept = append(seg.kept, kept)
+}
+
+func (seg *labelSegment) writeString(s string) {
+	for _, c := range s {
+		seg.write(c, true)
+	}
+}
+
+func (seg *labelSegment) String() string {
+	isSpace := func(i int) bool {
+		return !seg.kept[i] && (seg.runes[i] == ' ' || seg.runes[i] == '\t')
+	}
+	start, end := 0, len(seg.runes)
+	for start < end && isSpace(start) {
+		start++
+	}
+	for end > start && isSpace(end-1) {
+		end--
+	}
+	return string(seg.runes[start:end])
+}
+
+// closingIndex returns the index of the unescaped character c following runes[start], or -1 if not found.
+func closingIndex(runes []rune, start int, c rune) int {
+	for i := start + 1; i < len(runes); i++ {
+		if runes[i] == labelEscape && i+1 < len(runes) && strings.ContainsRune(labelSymbols, runes[i+1]) {
+			i++
+		} else if runes[i] == c {
+			return i
+		}
+	}
+	return -1
+}
+
+// listEndIndex returns the index of the bracket closing the list value started at runes[start], or -1 if not found.
+func listEndIndex(runes []rune, start int) int {
+	itemStart := true
+	for i := start + 1; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case c == labelEscape && i+1 < len(runes) && strings.ContainsRune(labelSymbols, runes[i+1]):
+			i++
+			itemStart = false
+		case itemStart && strings.ContainsRune(labelQuotes, c):
+			if end := closingIndex(runes, i, c); end >= 0 {
+				i = end
+			}
+			itemStart = false
+		case c == labelListEnd:
+			return i
+		case strings.ContainsRune(labelSeparators, c):
+			itemStart = true
+		case c == ' ' || c == '\t':
+		default:
+			itemStart = false
+		}
+	}
+	return -1
+}
+
+// ParseLabels splits a DOT attribute value into labels.
+// Labels are separated by "," or ";". In addition:
+//   - a quoted value keeps separators and spaces (e.g., desc="uplink, primary" or desc='uplink, primary')
+//   - backslash escapes a symbol (e.g., desc=uplink\, primary)
+//   - brackets describe a list value, joined with ListValueSeparator (e.g., comm=[65000:1, 65000:2])
+//
+// Quotes and brackets are special only at the start of a value (or a list item) and with the closing one,
+// so that other quotes and brackets are kept as they are (e.g., desc=Bob's box, or k=[a).
+func ParseLabels(value string) (labels []string) {
+	value = unquoteDotString(value)
 	if value == "" {
-		return classes
+		return labels
 	}
-	if SEPARATOR == nil {
-		SEPARATOR = regexp.MustCompile("[,;]")
+
+	label := &labelSegment{}
+	var items []string     // items of the current list value
+	var item *labelSegment // current list item, nil if not in a list value
+	var quote rune         // current quote character, 0 if not quoted
+	current := func() *labelSegment {
+		if item != nil {
+			return item
+		}
+		return label
 	}
-	for _, s := range SEPARATOR.Split(strings.Trim(value, "\""), -1) {
-		classes = append(classes, strings.TrimSpace(s))
+	valueStart := func() bool {
+		if item != nil {
+			return item.String() == ""
+		}
+		return strings.HasSuffix(label.String(), types.ValueLabelSeparator)
 	}
-	return classes
+
+	runes := []rune(value)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case c == labelEscape && i+1 < len(runes) && strings.ContainsRune(labelSymbols, runes[i+1]):
+			i++
+			current().write(runes[i], true)
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				current().write(c, true)
+			}
+		case strings.ContainsRune(labelQuotes, c) && valueStart() && closingIndex(runes, i, c) >= 0:
+			quote = c
+		case c == labelListStart && item == nil && valueStart() && listEndIndex(runes, i) >= 0:
+			item = &labelSegment{}
+		case c == labelListEnd && item != nil:
+			items = append(items, item.String())
+			label.writeString(strings.Join(items, ListValueSeparator))
+			items = nil
+			item = nil
+		case strings.ContainsRune(labelSeparators, c):
+			if item != nil {
+				items = append(items, item.String())
+				item = &labelSegment{}
+			} else {
+				labels = append(labels, label.String())
+				label = &labelSegment{}
+			}
+		default:
+			current().write(c, false)
+		}
+	}
+	labels = append(labels, label.String())
+	return labels
 }
@@ -0,0 +1,303 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/awalterschulze/gographviz"
+)
+
+// AttrLinkCount is a DOT edge attribute to describe multiple parallel links
+// with one edge statement (e.g., a -> b [count=4]).
+const AttrLinkCount = "count"
+
+// rangeNodePattern matches node names with range shorthand (e.g., leaf[1-16]).
+// A leading zero in the first index (e.g., leaf[01-16]) pads the expanded indexes.
+var rangeNodePattern = regexp.MustCompile(`^"?([A-Za-z0-9_\-.]*)\[([0-9]+)-([0-9]+)\]"?$`)
+
+// rangeTokenPattern matches range shorthand just after an unquoted DOT identifier.
+var rangeTokenPattern = regexp.MustCompile(`^\[[0-9]+-[0-9]+\]`)
+
+// lenientGraph is a gographviz.Interface implementation that accepts
+// any attribute names, including those unknown to graphviz (e.g., count).
+// gographviz.Graph rejects such attributes in its Analyse process.
+type lenientGraph struct {
+	*gographviz.Graph
+}
+
+func newLenientGraph() *lenientGraph {
+	return &lenientGraph{Graph: gographviz.NewGraph()}
+}
+
+func lenientAttrs(attrs map[string]string) gographviz.Attrs {
+	ret := gographviz.Attrs{}
+	for k, v := range attrs {
+		ret[gographviz.Attr(k)] = v
+	}
+	return ret
+}
+
+func (g *lenientGraph) AddPortEdge(src, srcPort, dst, dstPort string, directed bool, attrs map[string]string) error {
+	g.Edges.Add(&gographviz.Edge{
+		Src: src, SrcPort: srcPort, Dst: dst, DstPort: dstPort, Dir: directed, Attrs: lenientAttrs(attrs),
+	})
+	return nil
+}
+
+func (g *lenientGraph) AddEdge(src, dst string, directed bool, attrs map[string]string) error {
+	return g.AddPortEdge(src, "", dst, "", directed, attrs)
+}
+
+func (g *lenientGraph) AddNode(parentGraph string, name string, attrs map[string]string) error {
+	g.Nodes.Add(&gographviz.Node{Name: name, Attrs: lenientAttrs(attrs)})
+	g.Relations.Add(parentGraph, name)
+	return nil
+}
+
+func (g *lenientGraph) AddAttr(parentGraph string, field string, value string) error {
+	if parentGraph == g.Name {
+		g.Attrs[gographviz.Attr(field)] = value
+		return nil
+	}
+	sub, ok := g.SubGraphs.SubGraphs[parentGraph]
+	if !ok {
+		return fmt.Errorf("graph or subgraph %s does not exist", parentGraph)
+	}
+	sub.Attrs[gographviz.Attr(field)] = value
+	return nil
+}
+
+func (g *lenientGraph) AddSubGraph(parentGraph string, name string, attrs map[string]string) error {
+	g.Relations.Add(parentGraph, name)
+	g.SubGraphs.Add(name)
+	for key, value := range attrs {
+		if err := g.AddAttr(name, key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// quoteRangeTokens quotes node range shorthand (e.g., leaf[1-16] -> "leaf[1-16]")
+// so that the DOT parser accepts it as an identifier.
+// Quoted strings, HTML strings and comments are kept as is.
+func quoteRangeTokens(src []byte) []byte {
+	ret := make([]byte, 0, len(src))
+	isIDChar := func(c byte) bool {
+		return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+	}
+
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == '"':
+			// quoted string
+			j := i + 1
+			for j < len(src) && src[j] != '"' {
+				if src[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			j = min(j+1, len(src))
+			ret = append(ret, src[i:j]...)
+			i = j
+		case c == '<':
+			// HTML string
+			depth := 0
+			j := i
+			for j < len(src) {
+				if src[j] == '<' {
+					depth++
+				} else if src[j] == '>' {
+					depth--
+				}
+				j++
+				if depth == 0 {
+					break
+				}
+			}
+			ret = append(ret, src[i:j]...)
+			i = j
+		case c == '/' && i+1 < len(src) && src[i+1] == '/',
+			c == '#' && (i == 0 || src[i-1] == '\n'):
+			// line comment
+			j := i
+			for j < len(src) && src[j] != '\n' {
+				j++
+			}
+			ret = append(ret, src[i:j]...)
+			i = j
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			// block comment
+			j := i + 2
+			for j+1 < len(src) && !(src[j] == '*' && src[j+1] == '/') {
+				j++
+			}
+			j = min(j+2, len(src))
+			ret = append(ret, src[i:j]...)
+			i = j
+		case isIDChar(c):
+			j := i
+			for j < len(src) && isIDChar(src[j]) {
+				j++
+			}
+			if loc := rangeTokenPattern.FindIndex(src[j:]); loc != nil {
+				k := j + loc[1]
+				ret = append(ret, '"')
+				ret = append(ret, src[i:k]...)
+				ret = append(ret, '"')
+				i = k
+			} else {
+				ret = append(ret, src[i:j]...)
+				i = j
+			}
+		default:
+			ret = append(ret, c)
+			i++
+		}
+	}
+	return ret
+}
+
+// expandNodeName returns the node names described by a (range shorthand) node name.
+// The second return value is false if the name is not a range shorthand.
+func expandNodeName(name string) ([]string, bool, error) {
+	m := rangeNodePattern.FindStringSubmatch(name)
+	if m == nil {
+		return []string{name}, false, nil
+	}
+	prefix, startStr, endStr := m[1], m[2], m[3]
+	start, err := strconv.Atoi(startStr)
+	if err != nil {
+		return nil, true, fmt.Errorf("invalid node range %s: %w", name, err)
+	}
+	end, err := strconv.Atoi(endStr)
+	if err != nil {
+		return nil, true, fmt.Errorf("invalid node range %s: %w", name, err)
+	}
+	if start > end {
+		return nil, true, fmt.Errorf("invalid node range %s: start index is larger than end index", name)
+	}
+
+	format := "%s%d"
+	if len(startStr) > 1 && startStr[0] == '0' {
+		format = fmt.Sprintf("%%s%%0%dd", len(startStr))
+	}
+	names := make([]string, 0, end-start+1)
+	for i := start; i <= end; i++ {
+		names = append(names, fmt.Sprintf(format, prefix, i))
+	}
+	return names, true, nil
+}
+
+// getLinkCount returns the number of parallel links described by the count attribute.
+func getLinkCount(e *gographviz.Edge) (int, error) {
+	val, ok := e.Attrs[AttrLinkCount]
+	if !ok {
+		return 1, nil
+	}
+	cnt, err := strconv.Atoi(trimQuote(val))
+	if err != nil || cnt < 1 {
+		return 0, fmt.Errorf("invalid link count %s on edge %s -- %s", val, e.Src, e.Dst)
+	}
+	return cnt, nil
+}
+
+func trimQuote(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// expandShorthand expands node range shorthand (e.g., leaf[1-16]) and
+// link multiplicity (count attribute) into plain nodes and edges.
+// Edges between range nodes are expanded into full bipartite links.
+func (d *Diagram) expandShorthand() error {
+	g := d.graph
+
+	// expand nodes
+	nodes := make([]*gographviz.Node, len(g.Nodes.Nodes))
+	copy(nodes, g.Nodes.Nodes)
+	for _, node := range nodes {
+		names, isRange, err := expandNodeName(node.Name)
+		if err != nil {
+			return err
+		}
+		if !isRange {
+			continue
+		}
+
+		parents := []string{}
+		for parent := range g.Relations.ChildToParents[node.Name] {
+			parents = append(parents, parent)
+		}
+		for _, name := range names {
+			if existing, ok := g.Nodes.Lookup[name]; ok {
+				// labels on both range node and individual node are merged
+				existing.Attrs = mergeAttrs(existing.Attrs, node.Attrs)
+			} else {
+				g.Nodes.Add(&gographviz.Node{Name: name, Attrs: node.Attrs.Copy()})
+			}
+			for _, parent := range parents {
+				g.Relations.Add(parent, name)
+			}
+		}
+		if err := g.Nodes.Remove(node.Name); err != nil {
+			return err
+		}
+		for _, parent := range parents {
+			g.Relations.Remove(parent, node.Name)
+		}
+		delete(g.Relations.ChildToParents, node.Name)
+	}
+
+	// expand edges
+	edges := gographviz.NewEdges()
+	for _, e := range g.Edges.Edges {
+		srcs, srcIsRange, err := expandNodeName(e.Src)
+		if err != nil {
+			return err
+		}
+		dsts, dstIsRange, err := expandNodeName(e.Dst)
+		if err != nil {
+			return err
+		}
+		cnt, err := getLinkCount(e)
+		if err != nil {
+			return err
+		}
+		if !srcIsRange && !dstIsRange && cnt == 1 {
+			delete(e.Attrs, AttrLinkCount)
+			edges.Add(e)
+			continue
+		}
+
+		// a port name cannot be shared by multiple links of one node
+		if e.SrcPort != "" && (len(dsts) > 1 || cnt > 1) {
+			return fmt.Errorf("port %s of %s cannot be used for multiple links", e.SrcPort, e.Src)
+		}
+		if e.DstPort != "" && (len(srcs) > 1 || cnt > 1) {
+			return fmt.Errorf("port %s of %s cannot be used for multiple links", e.DstPort, e.Dst)
+		}
+
+		attrs := e.Attrs.Copy()
+		delete(attrs, AttrLinkCount)
+		for _, src := range srcs {
+			for _, dst := range dsts {
+				for i := 0; i < cnt; i++ {
+					edges.Add(&gographviz.Edge{
+						Src: src, SrcPort: e.SrcPort, Dst: dst, DstPort: e.DstPort,
+						Dir: e.Dir, Attrs: attrs.Copy(),
+					})
+				}
+			}
+		}
+	}
+	g.Edges = edges
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func diagramFromString(t *testing.T, content string) (*Diagram, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "input.dot")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return DiagramFromDotFile(path)
+}
+
+func TestDiagramShorthand(t *testing.T) {
+	t.Run("range_bipartite", func(t *testing.T) {
+		d, err := diagramFromString(t, `digraph {
+  spine[1-2] [xlabel="spine"];
+  leaf[1-3] [xlabel="leaf"];
+  leaf2 [xlabel="border"];
+  spine[1-2] -> leaf[1-3];
+}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(d.Nodes()) != 5 {
+			t.Errorf("number of nodes mismatch (%v)", len(d.Nodes()))
+		}
+		if len(d.Links()) != 6 {
+			t.Errorf("number of links mismatch (%v)", len(d.Links()))
+		}
+		if _, ok := d.graph.Nodes.Lookup["leaf[1-3]"]; ok {
+			t.Errorf("range node is not expanded")
+		}
+		labels := getNodeLabels(d.graph.Nodes.Lookup["leaf2"])
+		if len(labels) != 2 {
+			t.Errorf("labels of leaf2 are not merged (%v)", labels)
+		}
+	})
+
+	t.Run("zero_padding", func(t *testing.T) {
+		d, err := diagramFromString(t, `graph { r[08-10]; }`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range []string{"r08", "r09", "r10"} {
+			if _, ok := d.graph.Nodes.Lookup[name]; !ok {
+				t.Errorf("node %s not found", name)
+			}
+		}
+	})
+
+	t.Run("group_members", func(t *testing.T) {
+		d, err := diagramFromString(t, `graph {
+  subgraph cluster_pod1 { leaf[1-2]; }
+}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range []string{"leaf1", "leaf2"} {
+			groups := d.NodeGroups(name)
+			if len(groups) != 1 || groups[0].Name != "cluster_pod1" {
+				t.Errorf("group of %s mismatch (%v)", name, groups)
+			}
+		}
+	})
+
+	t.Run("link_count", func(t *testing.T) {
+		d, err := diagramFromString(t, `graph { a -- b [count=4, label="trunk"]; }`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(d.Links()) != 4 {
+			t.Errorf("number of links mismatch (%v)", len(d.Links()))
+		}
+		for _, e := range d.Links() {
+			if _, ok := e.Attrs[AttrLinkCount]; ok {
+				t.Errorf("count attribute remains on expanded link")
+			}
+		}
+	})
+
+	t.Run("quoted_string", func(t *testing.T) {
+		d, err := diagramFromString(t, `graph { a [xlabel="x[1-2]"]; }`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(d.Nodes()) != 1 {
+			t.Errorf("number of nodes mismatch (%v)", len(d.Nodes()))
+		}
+	})
+
+	t.Run("port_conflict", func(t *testing.T) {
+		_, err := diagramFromString(t, `graph { a:p1 -- b[1-2]; }`)
+		if err == nil {
+			t.Errorf("expected error for a port shared by multiple links")
+		}
+	})
+
+	t.Run("invalid_count", func(t *testing.T) {
+		_, err := diagramFromString(t, `graph { a -- b [count=0]; }`)
+		if err == nil {
+			t.Errorf("expected error for invalid link count")
+		}
+	})
+}
+
+func TestParseLabels(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected []string
+	}{
+		{"empty", ``, nil},
+		{"plain", `router`, []string{"router"}},
+		{"separators", `"router, bgp;as=65001"`, []string{"router", "bgp", "as=65001"}},
+		{"merged", `"leaf";"border"`, []string{"leaf", "border"}},
+		{"double_quoted", `"router,desc=\"uplink, primary\""`, []string{"router", "desc=uplink, primary"}},
+		{"single_quoted", `"desc='  a;b  ', router"`, []string{"desc=  a;b  ", "router"}},
+		{"escaped", `"desc=uplink\, primary\;x, router"`, []string{"desc=uplink, primary;x", "router"}},
+		{"backslash", `"path=C:\dir"`, []string{"path=C:\\dir"}},
+		{"list", `"comm=[65000:1, 65000:2], router"`, []string{"comm=65000:1 65000:2", "router"}},
+		{"list_quoted_item", `"comm=['a,b', c]"`, []string{"comm=a,b c"}},
+		{"bracket_not_value", `"a[1], b"`, []string{"a[1]", "b"}},
+		{"relational_class", `"interface#lo"`, []string{"interface#lo"}},
+		{"apostrophe", `"router, desc=Bob's box, bgp"`, []string{"router", "desc=Bob's box", "bgp"}},
+		{"apostrophe_class", `"it's, router"`, []string{"it's", "router"}},
+		{"unterminated_quote", `"desc='open, router"`, []string{"desc='open", "router"}},
+		{"unterminated_double_quote", `"desc=\"open; router"`, []string{"desc=\"open", "router"}},
+		{"unterminated_list", `"k=[a"`, []string{"k=[a"}},
+		{"unterminated_list_items", `"k=[a, b, router"`, []string{"k=[a", "b", "router"}},
+		{"quote_in_list", `"k=['a]', b]"`, []string{"k=a] b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			labels := ParseLabels(tt.value)
+			if len(labels) != len(tt.expected) {
+				t.Fatalf("labels mismatch: expected %q, got %q", tt.expected, labels)
+			}
+			for i := range labels {
+				if labels[i] != tt.expected[i] {
+					t.Errorf("label %d mismatch: expected %q, got %q", i, tt.expected[i], labels[i])
+				}
+			}
+		})
+	}
+}
@@ -301,14 +301,14 @@ func headN(s string, n int) string {
 	return string(runes[:n])
 }
 
-func getConfig(tpl *template.Template, namespace map[string]string) (string, error) {
+func getConfig(tpl *template.Template, data interface{}) (string, error) {
 	if tpl == nil {
 		return "", fmt.Errorf("template is nil")
 	}
 	tpl = tpl.Option("missingkey=error")
 
 	writer := new(strings.Builder)
-	err := tpl.Execute(writer, namespace)
+	err := tpl.Execute(writer, data)
 	if err != nil {
 		return "", fmt.Errorf("missing variables in parameters: %W", err)
 	}
@@ -602,7 +602,7 @@ func generateIndividualConfigs(cfg *types.Config, ca *ConfigAggregator, ns types
 	for _, ct := range reordered {
 		// Generate config block if conditions are met
 		var conf string
-		reason, met := checkConfigTemplateConditions(ns, ct, verbose)
+		reason, met := checkConfigTemplateConditions(cfg, ns, ct, verbose)
 		if met {
 			if verbose {
 				fmt.Fprintf(os.Stderr, "templating individual config for %s with %s\n", ns.StringForMessage(), ct.String())
@@ -677,7 +677,7 @@ func checkSorterObjects(cfg *types.Config, ca *ConfigAggregator, ns types.NameSp
 	cts := ns.GetPossibleConfigTemplates(cfg)
 	for _, ct := range cts {
 		// Check if the config template is valid and sorter
-		_, met := checkConfigTemplateConditions(ns, ct, false)
+		_, met := checkConfigTemplateConditions(cfg, ns, ct, false)
 		if met && ct.Style == types.ConfigTemplateStyleSort {
 			ca.addSorter(ns, ct.SortGroup)
 		}
@@ -686,7 +686,7 @@ func checkSorterObjects(cfg *types.Config, ca *ConfigAggregator, ns types.NameSp
 
 // func generateConfigBlock(ct *types.ConfigTemplate, ns types.NameSpacer) (string, error) {
 func generateConfigBlock(ns types.NameSpacer, configTemplate *types.ConfigTemplate) (string, error) {
-	conf, err := getConfig(configTemplate.ParsedTemplate, ns.GetRelativeParams())
+	conf, err := getConfig(configTemplate.ParsedTemplate, configTemplateData(ns, configTemplate))
 	if err != nil {
 		return EmptyOutput, fmt.Errorf("templating failure for %s, %w", ns.StringForMessage(), err)
 	}
@@ -895,7 +895,12 @@ func outputConfigFile(cfg *types.Config, ns types.NameSpacer, conf string, ct *t
 	return nil
 }
 
-func checkConfigTemplateConditions(ns types.NameSpacer, configTemplate *types.ConfigTemplate, verbose bool) (string, bool) {
+func checkConfigTemplateConditions(cfg *types.Config, ns types.NameSpacer, configTemplate *types.ConfigTemplate, verbose bool) (string, bool) {
+	// check if the config template is available on the selected platform
+	if !configTemplate.PlatformCheck(cfg.GlobalSettings.Platform) {
+		return "non-selected platform", false
+	}
+
 	if lo, ok := ns.(types.LabelOwner); ok {
 		// check virtual object or not if ns is LabelOwner
 		if lo.IsVirtual() {
@@ -0,0 +1,391 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+
+	"github.com/cpflat/dot2net/pkg/types"
+)
+
+// An IPAMLock records assigned addresses keyed by stable object identity (node names and segment endpoints).
+// The addresses in the lock file are reused on the next build if still available,
+// so that topology edits do not renumber existing objects.
+type IPAMLock struct {
+	// Management maps node names to management interface addresses
+	Management map[string]string `yaml:"management,omitempty"`
+	// Layers maps layer names to addresses of the layer
+	Layers map[string]*LayerIPAMLock `yaml:"layers,omitempty"`
+}
+
+type LayerIPAMLock struct {
+	// Loopbacks maps node names to loopback addresses
+	Loopbacks map[string]string `yaml:"loopbacks,omitempty"`
+	// Segments maps segment keys (endpoint node names, e.g., r1--r2) to segment prefixes
+	Segments map[string]string `yaml:"segments,omitempty"`
+	// Interfaces maps interface keys to addresses with prefix length (e.g., 10.0.0.1/30).
+	// The keys are node:interface for interfaces named in DOT, and node:segment key (e.g., r1:r1--r2)
+	// for automatically named interfaces, whose names shift when links are added before them.
+	Interfaces map[string]string `yaml:"interfaces,omitempty"`
+}
+
+func newIPAMLock() *IPAMLock {
+	return &IPAMLock{
+		Management: map[string]string{},
+		Layers:     map[string]*LayerIPAMLock{},
+	}
+}
+
+func (lock *IPAMLock) layer(name string) *LayerIPAMLock {
+	if _, ok := lock.Layers[name]; !ok {
+		lock.Layers[name] = &LayerIPAMLock{
+			Loopbacks:  map[string]string{},
+			Segments:   map[string]string{},
+			Interfaces: map[string]string{},
+		}
+	}
+	return lock.Layers[name]
+}
+
+// management returns the locked management address of the node.
+func (lock *IPAMLock) management(node string) (netip.Addr, bool) {
+	if lock == nil {
+		return netip.Addr{}, false
+	}
+	addr, err := netip.ParseAddr(lock.Management[node])
+	return addr, err == nil
+}
+
+// loopback returns the locked loopback address of the node in the layer.
+func (lock *IPAMLock) loopback(layer string, node string) (netip.Addr, bool) {
+	if lock == nil || lock.Layers[layer] == nil {
+		return netip.Addr{}, false
+	}
+	addr, err := netip.ParseAddr(lock.Layers[layer].Loopbacks[node])
+	return addr, err == nil
+}
+
+// interfaceAddr returns the locked address (with prefix length) of the interface key in the layer.
+func (lock *IPAMLock) interfaceAddr(layer string, key string) (netip.Prefix, bool) {
+	if lock == nil || lock.Layers[layer] == nil || key == "" {
+		return netip.Prefix{}, false
+	}
+	prefix, err := netip.ParsePrefix(lock.Layers[layer].Interfaces[key])
+	return prefix, err == nil
+}
+
+// segment returns the locked prefix of the segment of the key in the layer.
+func (lock *IPAMLock) segment(layer string, key string) (netip.Prefix, bool) {
+	if lock == nil || lock.Layers[layer] == nil {
+		return netip.Prefix{}, false
+	}
+	prefix, err := netip.ParsePrefix(lock.Layers[layer].Segments[key])
+	return prefix, err == nil
+}
+
+// segmentKeyOf returns the key of the segment consisting of the interfaces.
+// The key is the sorted endpoint node names, which are stable even if interface names are automatically assigned.
+func segmentKeyOf(ifaces []*types.Interface) string {
+	names := make([]string, 0, len(ifaces))
+	for _, iface := range ifaces {
+		names = append(names, iface.Node.Name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, "--")
+}
+
+// uniqueSegmentKeys returns the keys that identify only one segment (e.g., not parallel links).
+func uniqueSegmentKeys(segs [][]*types.Interface) map[string]bool {
+	cnt := map[string]int{}
+	for _, ifaces := range segs {
+		cnt[segmentKeyOf(ifaces)]++
+	}
+	ret := map[string]bool{}
+	for key, n := range cnt {
+		ret[key] = n == 1
+	}
+	return ret
+}
+
+// interfaceLockKeys returns the keys of the interfaces in the segments to be recorded in the lock file.
+// Interfaces named in DOT are identified by their names, and the others by the segment keys.
+// Interfaces not identified uniquely (e.g., automatically named interfaces on parallel links) have no keys.
+func interfaceLockKeys(segs [][]*types.Interface) map[*types.Interface]string {
+	unique := uniqueSegmentKeys(segs)
+	keys := map[*types.Interface]string{}
+	cnt := map[string]int{}
+	for _, ifaces := range segs {
+		segKey := segmentKeyOf(ifaces)
+		for _, iface := range ifaces {
+			var key string
+			if !iface.AutoNamed {
+				key = types.InterfaceValueKey(iface.Node.Name, iface.Name)
+			} else if unique[segKey] {
+				key = types.InterfaceValueKey(iface.Node.Name, segKey)
+			} else {
+				continue
+			}
+			keys[iface] = key
+			cnt[key]++
+		}
+	}
+	for iface, key := range keys {
+		if cnt[key] > 1 {
+			delete(keys, iface)
+		}
+	}
+	return keys
+}
+
+// ipamLockPath returns the path of the IPAM lock file, or empty string if not used.
+func ipamLockPath(cfg *types.Config) string {
+	path := cfg.GlobalSettings.IPAMLock
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return types.GetRelativeFilePath(path, cfg)
+}
+
+// LoadIPAMLock loads the IPAM lock file specified in the config.
+// It returns nil if the lock file is not used, and an empty lock if the file does not exist yet.
+func LoadIPAMLock(cfg *types.Config) (*IPAMLock, error) {
+	path := ipamLockPath(cfg)
+	if path == "" {
+		return nil, nil
+	}
+	lock := newIPAMLock()
+	bytes, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return lock, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(bytes, lock); err != nil {
+		return nil, fmt.Errorf("failed to parse IPAM lock file %s: %w", path, err)
+	}
+	if lock.Management == nil {
+		lock.Management = map[string]string{}
+	}
+	if lock.Layers == nil {
+		lock.Layers = map[string]*LayerIPAMLock{}
+	}
+	for _, llock := range lock.Layers {
+		if llock.Loopbacks == nil {
+			llock.Loopbacks = map[string]string{}
+		}
+		if llock.Segments == nil {
+			llock.Segments = map[string]string{}
+		}
+		if llock.Interfaces == nil {
+			llock.Interfaces = map[string]string{}
+		}
+	}
+	return lock, nil
+}
+
+// NewIPAMLock records the addresses assigned in the network model.
+// Objects removed from the topology are not recorded, so their addresses are released.
+func NewIPAMLock(cfg *types.Config, nm *types.NetworkModel) *IPAMLock {
+	lock := newIPAMLock()
+	if cfg.HasManagementLayer() {
+		mlayer := &cfg.ManagementLayer
+		for _, node := range nm.Nodes {
+			if iface := node.GetManagementInterface(); iface != nil {
+				if addr, err := iface.GetParamValue(mlayer.IPAddressReplacer()); err == nil {
+					lock.Management[node.Name] = addr
+				}
+			}
+		}
+	}
+	for _, layer := range cfg.Layers {
+		segs := [][]*types.Interface{}
+		for _, seg := range nm.NetworkSegments[layer.Name] {
+			segs = append(segs, seg.Interfaces)
+		}
+		unique := uniqueSegmentKeys(segs)
+		for _, ifaces := range segs {
+			key := segmentKeyOf(ifaces)
+			if !unique[key] || len(ifaces) == 0 {
+				continue
+			}
+			if prefix, err := ifaces[0].GetParamValue(layer.IPNetworkReplacer()); err == nil {
+				lock.layer(layer.Name).Segments[key] = prefix
+			}
+		}
+		for _, node := range nm.Nodes {
+			if addr, err := node.GetParamValue(layer.IPLoopbackReplacer()); err == nil {
+				lock.layer(layer.Name).Loopbacks[node.Name] = addr
+			}
+		}
+		for iface, key := range interfaceLockKeys(segs) {
+			addr, err := iface.GetParamValue(layer.IPAddressReplacer())
+			if err != nil {
+				continue
+			}
+			plen, err := iface.GetParamValue(layer.IPPrefixLengthReplacer())
+			if err != nil {
+				continue
+			}
+			lock.layer(layer.Name).Interfaces[key] = addr + "/" + plen
+		}
+	}
+	return lock
+}
+
+// SaveIPAMLock writes the addresses assigned in the network model into the IPAM lock file specified in the config.
+// It does nothing if the lock file is not used.
+func SaveIPAMLock(cfg *types.Config, nm *types.NetworkModel) error {
+	path := ipamLockPath(cfg)
+	if path == "" {
+		return nil
+	}
+	bytes, err := yaml.Marshal(NewIPAMLock(cfg, nm))
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, bytes, 0644)
+}
+
+// isAvailableAddr returns true if the address is in the pool range and its block is not reserved yet.
+func (pool *ipPool) isAvailableAddr(addr netip.Addr) bool {
+	if !pool.prefixRange.Contains(addr) {
+		return false
+	}
+	prefix, err := addr.Prefix(pool.bits)
+	if err != nil {
+		return false
+	}
+	idx, err := pool.prefixToIndex(prefix)
+	if err != nil {
+		return false
+	}
+	_, bound := pool.boundIndex[idx]
+	return !bound
+}
+
+// reuseLockedAddrs reserves the locked addresses of the objects in the pool and assigns them.
+// It returns the objects that need new addresses (not locked, or locked address is no longer available).
+func reuseLockedAddrs[T any](pool *ipPool, objs []T, locked func(T) (netip.Addr, bool), assign func(T, netip.Addr)) ([]T, error) {
+	rest := make([]T, 0, len(objs))
+	for _, obj := range objs {
+		addr, ok := locked(obj)
+		if !ok || !pool.isAvailableAddr(addr) {
+			rest = append(rest, obj)
+			continue
+		}
+		if err := pool.reserveAddr(addr); err != nil {
+			return nil, err
+		}
+		assign(obj, addr)
+	}
+	return rest, nil
+}
+
+// isHostAddr returns true if the address is available for hosts in the prefix
+// (i.e., not network address, nor broadcast address on IPv4 except for point-to-point prefixes).
+func isHostAddr(prefix netip.Prefix, addr netip.Addr, p2p bool) bool {
+	if !prefix.Contains(addr) {
+		return false
+	}
+	if p2p && types.IsPointToPointPrefix(prefix) {
+		return true
+	}
+	if addr == prefix.Masked().Addr() {
+		return false
+	}
+	if addr.Is4() && addr == lastAddrOf(prefix) {
+		return false
+	}
+	return true
+}
+
+// reserveLockedPrefix reserves the prefix in the pool and uses it for the segment if available.
+func (seg *netSegment) reserveLockedPrefix(pool *ipPool, locked netip.Prefix) bool {
+	if locked.Bits() != pool.bits {
+		return false
+	}
+	prefix := locked.Masked()
+	if !pool.isAvailableAddr(prefix.Addr()) {
+		return false
+	}
+	if err := pool.reservePrefix(prefix); err != nil {
+		return false
+	}
+	seg.prefix = prefix
+	seg.locked = true
+	return true
+}
+
+// reuseLockedPrefixes determines the prefixes of unbound segments in the pools by the lock file.
+// Segments are first matched by the segment keys (endpoint node names),
+// and then by the locked addresses of the member interfaces.
+func reuseLockedPrefixes(pools []*ipPool, layer *types.Layer, lock *IPAMLock, keys map[*types.Interface]string) {
+	if lock == nil {
+		return
+	}
+	segs := [][]*types.Interface{}
+	for _, pool := range pools {
+		for _, seg := range pool.segments {
+			segs = append(segs, seg.Interfaces())
+		}
+	}
+	unique := uniqueSegmentKeys(segs)
+	for _, pool := range pools {
+		for _, seg := range pool.segments {
+			key := segmentKeyOf(seg.Interfaces())
+			if seg.bound || !unique[key] {
+				continue
+			}
+			if locked, ok := lock.segment(layer.Name, key); ok {
+				seg.reserveLockedPrefix(pool, locked)
+			}
+		}
+	}
+	for _, pool := range pools {
+		for _, seg := range pool.segments {
+			if seg.bound || seg.locked {
+				continue
+			}
+			for _, iface := range seg.uifaces {
+				if locked, ok := lock.interfaceAddr(layer.Name, keys[iface]); ok && seg.reserveLockedPrefix(pool, locked) {
+					break
+				}
+			}
+		}
+	}
+}
+
+// reuseLockedAddrs returns the interfaces that need new addresses,
+// and the locked addresses of the other interfaces still available in the segment.
+func (seg *netSegment) reuseLockedAddrs(layer *types.Layer, lock *IPAMLock, keys map[*types.Interface]string, p2p bool) ([]*types.Interface, map[*types.Interface]netip.Addr) {
+	used := map[netip.Addr]struct{}{}
+	for _, addr := range seg.raddrs {
+		used[addr] = struct{}{}
+	}
+	if seg.vip.IsValid() {
+		used[seg.vip] = struct{}{}
+	}
+	rest := []*types.Interface{}
+	laddrs := map[*types.Interface]netip.Addr{}
+	for _, iface := range seg.uifaces {
+		locked, ok := lock.interfaceAddr(layer.Name, keys[iface])
+		if !ok || locked.Masked() != seg.prefix || !isHostAddr(seg.prefix, locked.Addr(), p2p) {
+			rest = append(rest, iface)
+			continue
+		}
+		if _, exists := used[locked.Addr()]; exists {
+			rest = append(rest, iface)
+			continue
+		}
+		used[locked.Addr()] = struct{}{}
+		laddrs[iface] = locked.Addr()
+	}
+	return rest, laddrs
+}
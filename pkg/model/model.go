@@ -3,6 +3,7 @@ package model
 import (
 	"fmt"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -26,7 +27,9 @@ const NumberNumber string = "number"
 // BuildNetworkModelForFileList builds a lightweight NetworkModel sufficient for file listing.
 // This function only processes the minimum required for FilesToGenerate() to work:
 // - Module loading (for FileDefinitions)
+// - Pod template instantiation
 // - Topology skeleton (nodes, interfaces, class labels)
+// - Class assignment with classify rules
 // - Class validation
 // It skips expensive operations like IP address assignment and parameter generation.
 func BuildNetworkModelForFileList(cfg *types.Config, d *Diagram) (nm *types.NetworkModel, err error) {
@@ -35,12 +38,22 @@ func BuildNetworkModelForFileList(cfg *types.Config, d *Diagram) (nm *types.Netw
 		return nil, err
 	}
 
+	err = expandPodTemplates(cfg, d)
+	if err != nil {
+		return nil, err
+	}
+
 	// build topology skeleton with class labels
 	nm, err = buildSkeleton(cfg, d)
 	if err != nil {
 		return nil, err
 	}
 
+	err = classifyObjects(cfg, nm)
+	if err != nil {
+		return nil, err
+	}
+
 	err = checkClasses(cfg, nm)
 	if err != nil {
 		return nil, err
@@ -56,12 +69,23 @@ func BuildNetworkModel(cfg *types.Config, d *Diagram, verbose bool) (nm *types.N
 		return nil, err
 	}
 
+	// instantiate pod templates (sub-topologies)
+	err = expandPodTemplates(cfg, d)
+	if err != nil {
+		return nil, err
+	}
+
 	// build topology
 	nm, err = buildSkeleton(cfg, d)
 	if err != nil {
 		return nil, err
 	}
 
+	err = classifyObjects(cfg, nm)
+	if err != nil {
+		return nil, err
+	}
+
 	err = checkClasses(cfg, nm)
 	if err != nil {
 		return nil, err
@@ -89,7 +113,7 @@ func BuildNetworkModel(cfg *types.Config, d *Diagram, verbose bool) (nm *types.N
 	}
 
 	// assign numbers, interface names and addresses
-	err = setGivenParameters(nm)
+	err = setGivenParameters(cfg, nm)
 	if err != nil {
 		return nil, err
 	}
@@ -104,7 +128,7 @@ func BuildNetworkModel(cfg *types.Config, d *Diagram, verbose bool) (nm *types.N
 		return nil, err
 	}
 
-	err = assignSegmentNames(nm)
+	err = assignSegmentNames(cfg, nm)
 	if err != nil {
 		return nil, err
 	}
@@ -185,6 +209,11 @@ func buildSkeleton(cfg *types.Config, d *Diagram) (*types.NetworkModel, error) {
 	for _, s := range d.graph.SubGraphs.SubGraphs {
 		group := nm.NewGroup(s.Name)
 		group.SetLabels(cfg, getSubGraphLabels(s), []string{})
+		if cfg.GlobalSettings.DotAttributes {
+			for k, v := range getDotAttrParams(s.Attrs) {
+				group.AddParam(k, v)
+			}
+		}
 	}
 
 	nm.Nodes = make([]*types.Node, 0, len(d.graph.Nodes.Nodes))
@@ -196,6 +225,11 @@ func buildSkeleton(cfg *types.Config, d *Diagram) (*types.NetworkModel, error) {
 		if err != nil {
 			return nil, err
 		}
+		if cfg.GlobalSettings.DotAttributes {
+			for k, v := range getDotAttrParams(n.Attrs) {
+				node.AddParam(k, v)
+			}
+		}
 		if groups, ok := d.nodeGroups[n.Name]; ok {
 			for _, name := range groups {
 				group, ok := nm.GroupByName(name)
@@ -249,6 +283,18 @@ func buildSkeleton(cfg *types.Config, d *Diagram) (*types.NetworkModel, error) {
 		if err != nil {
 			return nil, err
 		}
+		if cfg.GlobalSettings.DotAttributes {
+			params, srcParams, dstParams := getEdgeDotAttrParams(e)
+			for k, v := range params {
+				conn.AddParam(k, v)
+			}
+			for k, v := range srcParams {
+				srcIf.AddParam(k, v)
+			}
+			for k, v := range dstParams {
+				dstIf.AddParam(k, v)
+			}
+		}
 		// relational class label for interfaces
 		for _, rlabel := range conn.RelationalClassLabels() {
 			if rlabel.ClassType == types.ClassTypeInterface {
@@ -418,6 +464,7 @@ func assignInterfaceNames(nm *types.NetworkModel) error {
 					i++ // starts with 0, increment by loop
 				}
 				iface.Name = name
+				iface.AutoNamed = true
 				iface.Node.RenameInterface(iface, oldName, iface.Name)
 				existingNames[iface.Name] = struct{}{}
 				i++
@@ -486,14 +533,14 @@ func assignConnectionNames(nm *types.NetworkModel) error {
 	return nil
 }
 
-func assignSegmentNames(nm *types.NetworkModel) error {
+func assignSegmentNames(cfg *types.Config, nm *types.NetworkModel) error {
 	existingNames := map[string]struct{}{}
 	prefixMap := map[string][]*types.NetworkSegment{} // Segments to be named automatically
 
-	// Collect all segments from all layers
+	// Collect all segments from all layers (in the order of layers for consistent names)
 	var allSegments []*types.NetworkSegment
-	for _, segments := range nm.NetworkSegments {
-		allSegments = append(allSegments, segments...)
+	for _, layer := range cfg.Layers {
+		allSegments = append(allSegments, nm.NetworkSegments[layer.Name]...)
 	}
 
 	for _, segment := range allSegments {
@@ -541,53 +588,214 @@ func assignSegmentNames(nm *types.NetworkModel) error {
 	return nil
 }
 
-func setGivenParameters(nm *types.NetworkModel) error {
-	// add parameters only when no same key in namespace
-	addParam := func(lo types.LabelOwner, k string, v string) error {
-		switch obj := lo.(type) {
-		case *types.Connection:
-			// Connection requires special handling: add to both interfaces
-			if !obj.Src.HasParam(k) {
-				obj.Src.AddParam(k, v)
-			}
-			if !obj.Dst.HasParam(k) {
-				obj.Dst.AddParam(k, v)
-			}
-			// Also add to Connection itself (now NameSpacer)
-			if !obj.HasParam(k) {
-				obj.AddParam(k, v)
-			}
-		case types.NameSpacer: // *Node, *Interface, *Group, (*Connection handled above)
-			if !obj.HasParam(k) {
-				obj.AddParam(k, v)
+// checkObjectValueTargets returns an error if the object values file includes undefined objects.
+func checkObjectValueTargets(ov *types.ObjectValues, nm *types.NetworkModel) error {
+	if ov == nil {
+		return nil
+	}
+	for name := range ov.Nodes {
+		if _, ok := nm.NodeByName(name); !ok {
+			return fmt.Errorf("object values: node %s not found", name)
+		}
+	}
+	for key := range ov.Interfaces {
+		nodeName, ifaceName, _ := strings.Cut(key, types.ObjectValueSeparator)
+		node, ok := nm.NodeByName(nodeName)
+		if !ok {
+			return fmt.Errorf("object values: node %s of interface %s not found", nodeName, key)
+		}
+		if _, ok := node.InterfaceByName(ifaceName); !ok {
+			return fmt.Errorf("object values: interface %s not found", key)
+		}
+	}
+	connNames := map[string]bool{}
+	for _, conn := range nm.Connections {
+		connNames[conn.Name] = true
+	}
+	for name := range ov.Connections {
+		if !connNames[name] {
+			return fmt.Errorf("object values: connection %s not found", name)
+		}
+	}
+	for name := range ov.Groups {
+		if _, ok := nm.GroupByName(name); !ok {
+			return fmt.Errorf("object values: group %s not found", name)
+		}
+	}
+	return nil
+}
+
+// givenValue is a parameter value given by users with its source.
+type givenValue struct {
+	value  string
+	source string
+}
+
+// sourceLevel returns the level of the source in global.value_precedence (label, object or class).
+// Values given via connections (e.g., "connection connectionclass jumbo") have the level of the original source.
+func (v givenValue) sourceLevel() string {
+	switch strings.TrimPrefix(v.source, "connection ") {
+	case "label":
+		return types.ValueSourceLabel
+	case "object_values":
+		return types.ValueSourceObject
+	default:
+		return types.ValueSourceClass
+	}
+}
+
+// classNameOf returns a class description for messages.
+func classNameOf(cls types.ObjectClass) string {
+	switch c := cls.(type) {
+	case *types.NetworkClass:
+		return "networkclass " + c.Name
+	case *types.NodeClass:
+		return "nodeclass " + c.Name
+	case *types.InterfaceClass:
+		return "interfaceclass " + c.Name
+	case *types.ConnectionClass:
+		return "connectionclass " + c.Name
+	case *types.GroupClass:
+		return "groupclass " + c.Name
+	default:
+		return fmt.Sprintf("%T", cls)
+	}
+}
+
+// sortedKeys returns keys of the map in ascending order, for deterministic iteration.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// reportValueConflict handles a conflict of given values based on global.value_conflict.
+// Values of different levels (e.g., a DOT label overriding a class value) follow global.value_precedence
+// as intended, so only conflicts of the same level (e.g., between classes) are reported.
+func reportValueConflict(cfg *types.Config, ns types.NameSpacer, k string, winner, loser givenValue) error {
+	if winner.sourceLevel() != loser.sourceLevel() {
+		return nil
+	}
+	msg := fmt.Sprintf("conflicting values of %s in %s: %q (%s) overrides %q (%s)",
+		k, ns.StringForMessage(), winner.value, winner.source, loser.value, loser.source)
+	switch cfg.GlobalSettings.GetValueConflict() {
+	case types.ValueConflictError:
+		return fmt.Errorf("%s", msg)
+	case types.ValueConflictWarn:
+		fmt.Fprintf(os.Stderr, "warning: %s\n", msg)
+	}
+	return nil
+}
+
+// resolveGivenValues merges values given to the object in the order of global.value_precedence.
+// Within class values, a later class overrides an earlier class
+// (conflicts among nodeclasses or interfaceclasses are already rejected in SetClasses).
+func resolveGivenValues(cfg *types.Config, lo types.LabelOwner) (map[string]givenValue, error) {
+	sources := map[string][]map[string]givenValue{}
+	toGiven := func(values map[string]string, source string) map[string]givenValue {
+		ret := make(map[string]givenValue, len(values))
+		for k, v := range values {
+			ret[k] = givenValue{value: v, source: source}
+		}
+		return ret
+	}
+	sources[types.ValueSourceLabel] = []map[string]givenValue{toGiven(lo.ValueLabels(), "label")}
+	sources[types.ValueSourceObject] = []map[string]givenValue{toGiven(cfg.ObjectValues.ValuesOf(lo), "object_values")}
+	for _, cls := range lo.GetClasses() {
+		loClass, ok := cls.(types.LabelOwnerClass)
+		if !ok {
+			return nil, fmt.Errorf("unexpected class type %T for setGivenParameters", cls)
+		}
+		sources[types.ValueSourceClass] = append(sources[types.ValueSourceClass],
+			toGiven(loClass.GetGivenValues(), classNameOf(cls)))
+	}
+
+	// merge from the lowest priority
+	precedence := cfg.GlobalSettings.GetValuePrecedence()
+	resolved := map[string]givenValue{}
+	for i := len(precedence) - 1; i >= 0; i-- {
+		for _, values := range sources[precedence[i]] {
+			for _, k := range sortedKeys(values) {
+				v := values[k]
+				if old, ok := resolved[k]; ok && old.value != v.value {
+					if err := reportValueConflict(cfg, lo.(types.NameSpacer), k, v, old); err != nil {
+						return nil, err
+					}
+				}
+				resolved[k] = v
 			}
-		default:
-			return fmt.Errorf("unexpected type %T for setGivenParameters", lo)
+		}
+	}
+	return resolved, nil
+}
+
+// setGivenParameters sets parameters given by users in DOT labels, the object values file and class values.
+// The precedence of the sources follows global.value_precedence (default: label > object > class),
+// and conflicts are reported based on global.value_conflict.
+// Values of connections are also given to both interfaces, but the values of interfaces are prioritized.
+// Parameters already set in the namespace are not overwritten.
+func setGivenParameters(cfg *types.Config, nm *types.NetworkModel) error {
+	err := checkObjectValueTargets(cfg.ObjectValues, nm)
+	if err != nil {
+		return err
+	}
+
+	// add parameters only when no same key in namespace
+	addParam := func(ns types.NameSpacer, k string, v givenValue) error {
+		if !ns.HasParam(k) {
+			ns.AddParam(k, v.value)
+			ns.SetParamSource(k, v.source)
+			return nil
+		}
+		// report conflicts with other given values (e.g., interface values and connection values)
+		if src := ns.GetParamSource(k); src != "" && ns.GetParams()[k] != v.value {
+			return reportValueConflict(cfg, ns, k, givenValue{value: ns.GetParams()[k], source: src}, v)
 		}
 		return nil
 	}
 
+	connections := []*types.Connection{}
 	for _, lo := range nm.LabelOwners() {
-		// set values in ValueLabels
-		for k, v := range lo.ValueLabels() {
-			err := addParam(lo, k, v)
-			if err != nil {
+		if conn, ok := lo.(*types.Connection); ok {
+			// connections are processed later to prioritize the values of interfaces
+			connections = append(connections, conn)
+			continue
+		}
+		ns, ok := lo.(types.NameSpacer)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for setGivenParameters", lo)
+		}
+		values, err := resolveGivenValues(cfg, lo)
+		if err != nil {
+			return err
+		}
+		for _, k := range sortedKeys(values) {
+			if err := addParam(ns, k, values[k]); err != nil {
 				return err
 			}
 		}
+	}
 
-		// set values in config
-		for _, cls := range lo.GetClasses() {
-			if loClass, ok := cls.(types.LabelOwnerClass); ok {
-				values := loClass.GetGivenValues()
-				for k, v := range values {
-					err := addParam(lo, k, v)
-					if err != nil {
-						return err
-					}
-				}
-			} else {
-				return fmt.Errorf("unexpected class type %T for setGivenParameters", cls)
+	for _, conn := range connections {
+		values, err := resolveGivenValues(cfg, conn)
+		if err != nil {
+			return err
+		}
+		for _, k := range sortedKeys(values) {
+			v := values[k]
+			if err := addParam(conn, k, v); err != nil {
+				return err
+			}
+			// Connection requires special handling: add to both interfaces
+			ifaceValue := givenValue{value: v.value, source: "connection " + v.source}
+			if err := addParam(conn.Src, k, ifaceValue); err != nil {
+				return err
+			}
+			if err := addParam(conn.Dst, k, ifaceValue); err != nil {
+				return err
 			}
 		}
 	}
@@ -632,8 +840,14 @@ func setGivenParameters(nm *types.NetworkModel) error {
 }
 
 func assignIPParameters(cfg *types.Config, nm *types.NetworkModel, verbose bool) error {
+	// addresses in the lock file are reused if available (nil if not used)
+	lock, err := LoadIPAMLock(cfg)
+	if err != nil {
+		return err
+	}
+
 	if cfg.HasManagementLayer() {
-		err := assignManagementIPAddresses(cfg, nm)
+		err := assignManagementIPAddresses(cfg, nm, lock)
 		if err != nil {
 			return err
 		}
@@ -641,7 +855,7 @@ func assignIPParameters(cfg *types.Config, nm *types.NetworkModel, verbose bool)
 
 	for _, layer := range cfg.Layers {
 		// loopback
-		err := assignIPLoopbacks(nm, layer)
+		err := assignIPLoopbacks(nm, layer, lock)
 		if err != nil {
 			return err
 		}
@@ -667,7 +881,7 @@ func assignIPParameters(cfg *types.Config, nm *types.NetworkModel, verbose bool)
 		setNeighbors(segs, layer)
 
 		// assign ip addresses
-		err = assignIPAddresses(nm, layer)
+		err = assignIPAddresses(nm, layer, lock)
 		if err != nil {
 			return err
 		}
@@ -681,6 +895,12 @@ func assignParameters(cfg *types.Config, nm *types.NetworkModel) error {
 	if err != nil {
 		return err
 	}
+	// group parameters are assigned before nodes and interfaces
+	// to be used in offsets of assign: group
+	err = assignGroupParameters(cfg, nm)
+	if err != nil {
+		return err
+	}
 	err = assignNodeParameters(cfg, nm)
 	if err != nil {
 		return err
@@ -697,7 +917,8 @@ func assignParameters(cfg *types.Config, nm *types.NetworkModel) error {
 	if err != nil {
 		return err
 	}
-	err = assignGroupParameters(cfg, nm)
+	// computed parameters are available in param_format of attach mode
+	err = assignComputedParameters(cfg, nm)
 	if err != nil {
 		return err
 	}
@@ -9,10 +9,23 @@ import (
 	"github.com/cpflat/dot2net/pkg/types"
 )
 
+// modulePlatforms maps modules to the platforms they generate files for.
+// Modules not listed here are loaded on any platform.
+var modulePlatforms = map[string]string{
+	"tinet":        types.OutputTinet,
+	"containerlab": types.OutputClab,
+}
+
 func LoadModules(cfg *types.Config) error {
 	var m types.Module
 	modules := []types.Module{}
 	for _, name := range cfg.Modules {
+		// skip modules for non-selected platforms
+		if platform, ok := modulePlatforms[name]; ok {
+			if cfg.GlobalSettings.Platform != "" && cfg.GlobalSettings.Platform != platform {
+				continue
+			}
+		}
 
 		// load modules based on given names in Config.Modules
 		switch name {
@@ -0,0 +1,253 @@
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/cpflat/dot2net/pkg/types"
+)
+
+// objectNameOf returns the name of the object used as "value" of the objects source.
+func objectNameOf(obj types.NameSpacer) string {
+	switch o := obj.(type) {
+	case *types.Node:
+		return o.Name
+	case *types.Interface:
+		return types.InterfaceValueKey(o.Node.Name, o.Name)
+	case *types.Connection:
+		return o.Name
+	case *types.Group:
+		return o.Name
+	case *types.NetworkSegment:
+		return o.Name
+	default:
+		return obj.StringForMessage()
+	}
+}
+
+// inGroup returns true if the node belongs to the group of the name or class.
+func inGroup(node *types.Node, name string) bool {
+	for _, group := range node.Groups {
+		if group.Name == name || group.HasClass(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// ownerInterfaces returns the interfaces of the owner (node or interface).
+func ownerInterfaces(owner types.ValueOwner) []*types.Interface {
+	switch o := owner.(type) {
+	case *types.Node:
+		return o.Interfaces
+	case *types.Interface:
+		return []*types.Interface{o}
+	default:
+		return nil
+	}
+}
+
+// neighborInterfaces returns the interfaces in the network segments of the layer with the owner interfaces.
+func neighborInterfaces(owner types.ValueOwner, layer string) (map[*types.Interface]bool, error) {
+	ifaces := ownerInterfaces(owner)
+	if ifaces == nil {
+		return nil, fmt.Errorf("neighbor_layer is available only for node and interface owners, not %s", owner.StringForMessage())
+	}
+	own := map[*types.Interface]bool{}
+	for _, iface := range ifaces {
+		own[iface] = true
+	}
+	ret := map[*types.Interface]bool{}
+	for _, iface := range ifaces {
+		seg, ok := iface.Segments[layer]
+		if !ok {
+			continue
+		}
+		for _, n := range seg.Interfaces {
+			if !own[n] {
+				ret[n] = true
+			}
+		}
+	}
+	return ret, nil
+}
+
+// isSelf returns true if the object is the owner, or the node of the owner interface.
+func isSelf(obj types.NameSpacer, owner types.ValueOwner) bool {
+	if obj == types.NameSpacer(owner) {
+		return true
+	}
+	if iface, ok := owner.(*types.Interface); ok {
+		return obj == types.NameSpacer(iface.Node)
+	}
+	if node, ok := owner.(*types.Node); ok {
+		if iface, ok := obj.(*types.Interface); ok {
+			return iface.Node == node
+		}
+	}
+	return false
+}
+
+// listSourceObjects lists candidate objects of the objects source in the order of the network model.
+func listSourceObjects(nm *types.NetworkModel, src *types.ParameterRuleSource, owner types.ValueOwner) ([]types.NameSpacer, error) {
+	var neighbors map[*types.Interface]bool
+	if src.NeighborLayer != "" {
+		var err error
+		neighbors, err = neighborInterfaces(owner, src.NeighborLayer)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	objs := []types.NameSpacer{}
+	switch src.Object {
+	case types.ClassTypeNode:
+		for _, node := range nm.Nodes {
+			if src.Group != "" && !inGroup(node, src.Group) {
+				continue
+			}
+			if neighbors != nil {
+				adjacent := false
+				for _, iface := range node.Interfaces {
+					adjacent = adjacent || neighbors[iface]
+				}
+				if !adjacent {
+					continue
+				}
+			}
+			objs = append(objs, node)
+		}
+	case types.ClassTypeInterface:
+		for _, node := range nm.Nodes {
+			if src.Group != "" && !inGroup(node, src.Group) {
+				continue
+			}
+			for _, iface := range node.Interfaces {
+				if neighbors != nil && !neighbors[iface] {
+					continue
+				}
+				objs = append(objs, iface)
+			}
+		}
+	case types.ClassTypeConnection:
+		for _, conn := range nm.Connections {
+			objs = append(objs, conn)
+		}
+	case types.ClassTypeGroup:
+		for _, group := range sortedGroups(nm) {
+			if src.Group != "" && group.Name != src.Group && !group.HasClass(src.Group) {
+				continue
+			}
+			objs = append(objs, group)
+		}
+	case types.ClassTypeSegment:
+		layers := sortedKeys(nm.NetworkSegments)
+		for _, layer := range layers {
+			if src.Layer != "" && layer != src.Layer {
+				continue
+			}
+			for _, seg := range nm.NetworkSegments[layer] {
+				objs = append(objs, seg)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown object type %q in objects source", src.Object)
+	}
+	return objs, nil
+}
+
+// sortedGroups returns the groups in the order of names (nm.Groups is in the order of DOT subgraph map).
+func sortedGroups(nm *types.NetworkModel) []*types.Group {
+	groups := append([]*types.Group{}, nm.Groups...)
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+	return groups
+}
+
+// compareParamValues compares parameter values numerically if both are integers, or as strings.
+func compareParamValues(a, b string) bool {
+	ia, errA := strconv.Atoi(a)
+	ib, errB := strconv.Atoi(b)
+	if errA == nil && errB == nil {
+		return ia < ib
+	}
+	return a < b
+}
+
+// generateValueParamsFromObjects generates parameter sets from objects in the network model (source type objects).
+// Each parameter set has "value" (object name), "index" and the object parameters with the prefix (default: obj_).
+func generateValueParamsFromObjects(nm *types.NetworkModel, rule *types.ParameterRule, owner types.ValueOwner) ([]map[string]string, error) {
+	src := rule.Source
+	candidates, err := listSourceObjects(nm, src, owner)
+	if err != nil {
+		return nil, fmt.Errorf("param_rule %s: %w", rule.Name, err)
+	}
+
+	var filter *template.Template
+	if src.Filter != "" {
+		filter, err = types.NewTemplate().Parse(src.Filter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter of param_rule %s: %w", rule.Name, err)
+		}
+	}
+
+	objs := []types.NameSpacer{}
+	for _, obj := range candidates {
+		if src.ExcludeSelf && isSelf(obj, owner) {
+			continue
+		}
+		if src.Class != "" {
+			lo, ok := obj.(types.LabelOwner)
+			if !ok || !lo.HasClass(src.Class) {
+				continue
+			}
+		}
+		if filter != nil {
+			data := map[string]interface{}{ParamFormatOwnerKey: owner.GetParams()}
+			for k, v := range obj.GetParams() {
+				data[k] = v
+			}
+			buf := &bytes.Buffer{}
+			if err := filter.Execute(buf, data); err != nil {
+				return nil, fmt.Errorf("failed to filter %s for param_rule %s: %w", obj.StringForMessage(), rule.Name, err)
+			}
+			if strings.TrimSpace(buf.String()) != "true" {
+				continue
+			}
+		}
+		objs = append(objs, obj)
+	}
+
+	if src.SortBy != "" {
+		for _, obj := range objs {
+			if !obj.HasParam(src.SortBy) {
+				return nil, fmt.Errorf("param_rule %s: sort key %s not found in %s", rule.Name, src.SortBy, obj.StringForMessage())
+			}
+		}
+		sort.SliceStable(objs, func(i, j int) bool {
+			return compareParamValues(objs[i].GetParams()[src.SortBy], objs[j].GetParams()[src.SortBy])
+		})
+	}
+	if rule.Sort == "desc" {
+		for i, j := 0, len(objs)-1; i < j; i, j = i+1, j-1 {
+			objs[i], objs[j] = objs[j], objs[i]
+		}
+	}
+
+	prefix := src.GetParamPrefix()
+	params := make([]map[string]string, 0, len(objs))
+	for i, obj := range objs {
+		paramSet := map[string]string{
+			"value": objectNameOf(obj),
+			"index": fmt.Sprintf("%d", i),
+		}
+		for k, v := range obj.GetParams() {
+			paramSet[prefix+k] = v
+		}
+		params = append(params, paramSet)
+	}
+	return params, nil
+}
@@ -2,13 +2,16 @@ package model
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
+	"text/template"
 
 	mapset "github.com/deckarep/golang-set/v2"
 	"github.com/goccy/go-yaml"
@@ -36,6 +39,8 @@ func getParameterCandidates(cfg *types.Config, rule *types.ParameterRule, cnt in
 				break
 			}
 		}
+	case types.ParameterRuleTypeMAC:
+		return getMACParameterCandidates(cfg, rule, cnt)
 	default: // "int"
 		if rule.Max > 0 && rule.Max-rule.Min < cnt {
 			return nil, fmt.Errorf("not enough candidates for %s (%d required)", rule.Name, cnt)
@@ -48,6 +53,46 @@ func getParameterCandidates(cfg *types.Config, rule *types.ParameterRule, cnt in
 	return params, nil
 }
 
+// getMACParameterCandidates generates MAC addresses sequentially from the offset min in the prefix.
+// Without prefix, a locally administered /24 derived from the network name is used
+// to avoid collisions between labs sharing a host (independent of the seed, so that changing the seed keeps MACs).
+func getMACParameterCandidates(cfg *types.Config, rule *types.ParameterRule, cnt int) ([]string, error) {
+	var prefix uint64
+	var bits int
+	if rule.Prefix == "" {
+		prefix, bits = types.DefaultMACPrefix(cfg.Name)
+	} else {
+		var err error
+		prefix, bits, err = types.ParseMACPrefix(rule.Prefix)
+		if err != nil {
+			return nil, fmt.Errorf("invalid parameter rule %s: %w", rule.Name, err)
+		}
+	}
+
+	start := uint64(rule.Min)
+	if start == 0 {
+		// avoid the address same as the prefix
+		start = 1
+	}
+	last := uint64(1)<<uint(48-bits) - 1
+	if rule.Max > 0 && uint64(rule.Max) < last {
+		last = uint64(rule.Max)
+	}
+	if start > last || last-start+1 < uint64(cnt) {
+		return nil, fmt.Errorf("not enough candidates for %s (%d required)", rule.Name, cnt)
+	}
+
+	params := make([]string, 0, cnt)
+	for i := 0; i < cnt; i++ {
+		mac, err := types.FormatMAC(prefix+start+uint64(i), rule.Format)
+		if err != nil {
+			return nil, fmt.Errorf("invalid parameter rule %s: %w", rule.Name, err)
+		}
+		params = append(params, rule.Header+mac+rule.Footer)
+	}
+	return params, nil
+}
+
 // =============================================================================
 // Generic helpers for distribute mode parameter assignment
 // =============================================================================
@@ -112,15 +157,21 @@ func assignDistributeParamsToObjects[T types.ValueOwner](
 			return fmt.Errorf("invalid parameter rule name %s", key)
 		}
 		// Skip attach mode rules (handled separately by assignAttachModeParameters)
-		if rule.IsAttachMode() {
+		// and computed rules (handled by assignComputedParameters)
+		if rule.IsAttachMode() || rule.IsComputed() {
 			continue
 		}
 
-		// Currently only "object" assign mode is supported in this generic function.
+		// "object", "group" and "node" assign modes are supported in this generic function.
 		// Interface-specific "segment" and "connection" modes are handled separately.
 		switch rule.Assign {
+		case types.ParameterRuleAssignGroup, types.ParameterRuleAssignNode:
+			err := assignScopedParams(cfg, rule, objects)
+			if err != nil {
+				return err
+			}
 		default:
-			params, err := getParameterCandidates(cfg, rule, len(objects))
+			params, err := getObjectParameterCandidates(cfg, rule, objects)
 			if err != nil {
 				return err
 			}
@@ -132,6 +183,102 @@ func assignDistributeParamsToObjects[T types.ValueOwner](
 	return nil
 }
 
+// paramScopeOf returns the group or node in which the numbering of the object restarts.
+// Nil is returned if the node of the object belongs to no (matching) group.
+func paramScopeOf(rule *types.ParameterRule, obj types.NameSpacer) (types.NameSpacer, error) {
+	var node *types.Node
+	switch o := obj.(type) {
+	case *types.Node:
+		if rule.Assign == types.ParameterRuleAssignNode {
+			return nil, fmt.Errorf("invalid parameter rule %s: assign: node is available only for interfaces", rule.Name)
+		}
+		node = o
+	case *types.Interface:
+		if rule.Assign == types.ParameterRuleAssignNode {
+			return o.Node, nil
+		}
+		node = o.Node
+	default:
+		return nil, fmt.Errorf("invalid parameter rule %s: assign: %s is not available for %s",
+			rule.Name, rule.Assign, obj.StringForMessage())
+	}
+
+	// node.Groups are ordered from the innermost group
+	for _, group := range node.Groups {
+		if rule.GroupClass == "" || group.HasClass(rule.GroupClass) {
+			return group, nil
+		}
+	}
+	return nil, nil
+}
+
+// getParamScopeOffset evaluates the offset template of the rule with the parameters of the scope.
+func getParamScopeOffset(rule *types.ParameterRule, scope types.NameSpacer) (int, error) {
+	if rule.Offset == "" || scope == nil {
+		return 0, nil
+	}
+	tpl, err := types.NewTemplate().Parse(rule.Offset)
+	if err != nil {
+		return 0, err
+	}
+	buf := &bytes.Buffer{}
+	if err := tpl.Execute(buf, scope.GetParams()); err != nil {
+		return 0, fmt.Errorf("invalid offset of parameter rule %s for %s: %w", rule.Name, scope.StringForMessage(), err)
+	}
+	offset, err := strconv.Atoi(strings.TrimSpace(buf.String()))
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid offset %q of parameter rule %s for %s", buf.String(), rule.Name, scope.StringForMessage())
+	}
+	return offset, nil
+}
+
+// assignScopedParams assigns parameters with numbering restarted in each group or node (assign: group or node).
+// The objects of each scope are numbered in the given order, from the offset of the scope.
+// Objects without group are numbered together as another scope.
+func assignScopedParams[T types.NameSpacer](cfg *types.Config, rule *types.ParameterRule, objects []T) error {
+	scopes := []types.NameSpacer{}
+	scopedObjects := map[types.NameSpacer][]T{}
+	for _, obj := range objects {
+		scope, err := paramScopeOf(rule, obj)
+		if err != nil {
+			return err
+		}
+		if _, ok := scopedObjects[scope]; !ok {
+			scopes = append(scopes, scope)
+		}
+		scopedObjects[scope] = append(scopedObjects[scope], obj)
+	}
+
+	for _, scope := range scopes {
+		objs := scopedObjects[scope]
+		var params []string
+		if rule.IsSeeded() {
+			var err error
+			params, err = getObjectParameterCandidates(cfg, rule, objs)
+			if err != nil {
+				return err
+			}
+		} else {
+			offset, err := getParamScopeOffset(rule, scope)
+			if err != nil {
+				return err
+			}
+			candidates, err := getParameterCandidates(cfg, rule, offset+len(objs))
+			if err != nil {
+				return err
+			}
+			if len(candidates) < offset+len(objs) {
+				return fmt.Errorf("not enough candidates for %s (%d required)", rule.Name, offset+len(objs))
+			}
+			params = candidates[offset:]
+		}
+		for i, obj := range objs {
+			obj.AddParam(rule.Name, params[i])
+		}
+	}
+	return nil
+}
+
 func assignNetworkParameters(cfg *types.Config, nm *types.NetworkModel) error {
 	// Note: cfg.NetworkClasses.Name are not used as network name because a network may belong to multiple network classes
 	nm.AddParam(types.ReservedParamName, cfg.Name)
@@ -178,11 +325,16 @@ func assignInterfaceParameters(cfg *types.Config, nm *types.NetworkModel) error
 		if !ok {
 			return fmt.Errorf("invalid parameter rule name %s", key)
 		}
-		// Skip attach mode rules (handled separately)
-		if rule.IsAttachMode() {
+		// Skip attach mode rules and computed rules (handled separately)
+		if rule.IsAttachMode() || rule.IsComputed() {
 			continue
 		}
 		switch rule.Assign {
+		case types.ParameterRuleAssignGroup, types.ParameterRuleAssignNode:
+			err := assignScopedParams(cfg, rule, ifaces)
+			if err != nil {
+				return err
+			}
 		case "segment":
 			// assign parameters per segment
 			// interfaces in the same segment should have the same parameter
@@ -213,7 +365,7 @@ func assignInterfaceParameters(cfg *types.Config, nm *types.NetworkModel) error
 			}
 
 			// assign parameters for parameter-aware objects
-			params, err := getParameterCandidates(cfg, rule, len(targetObjects))
+			params, err := getObjectParameterCandidates(cfg, rule, targetSegments)
 			if err != nil {
 				return err
 			}
@@ -244,7 +396,7 @@ func assignInterfaceParameters(cfg *types.Config, nm *types.NetworkModel) error
 			}
 
 			// assign parameters for parameter-aware objects
-			params, err := getParameterCandidates(cfg, rule, len(targetObjects))
+			params, err := getObjectParameterCandidates(cfg, rule, targetConnections)
 			if err != nil {
 				return err
 			}
@@ -255,7 +407,7 @@ func assignInterfaceParameters(cfg *types.Config, nm *types.NetworkModel) error
 			}
 		default:
 			// assign parameters per interface
-			params, err := getParameterCandidates(cfg, rule, len(ifaces))
+			params, err := getObjectParameterCandidates(cfg, rule, ifaces)
 			if err != nil {
 				return err
 			}
@@ -314,22 +466,26 @@ func assignSegmentParameters(cfg *types.Config, nm *types.NetworkModel) error {
 // generateValueParamsFromSource generates parameter sets from param_rule source.
 // Returns a slice of parameter maps, one per Value to be created.
 func generateValueParamsFromSource(cfg *types.Config, rule *types.ParameterRule) ([]map[string]string, error) {
-	var params []map[string]string
-
 	if rule.Source == nil {
 		return nil, fmt.Errorf("param_rule %s has no source", rule.Name)
 	}
+	return generateParamsFromSource(cfg, rule.Name, rule.Source)
+}
 
-	switch rule.Source.Type {
+// generateParamsFromSource generates parameter sets from a source of the param_rule.
+func generateParamsFromSource(cfg *types.Config, ruleName string, src *types.ParameterRuleSource) ([]map[string]string, error) {
+	var params []map[string]string
+
+	switch src.Type {
 	case "range":
-		for i := rule.Source.Start; i <= rule.Source.End; i++ {
+		for i := src.Start; i <= src.End; i++ {
 			paramSet := make(map[string]string)
 			paramSet["value"] = fmt.Sprintf("%d", i)
-			paramSet["index"] = fmt.Sprintf("%d", i-rule.Source.Start)
+			paramSet["index"] = fmt.Sprintf("%d", i-src.Start)
 			params = append(params, paramSet)
 		}
 	case "sequence":
-		count := rule.Source.End - rule.Source.Start
+		count := src.End - src.Start
 		if count <= 0 {
 			count = 10 // default count
 		}
@@ -340,7 +496,7 @@ func generateValueParamsFromSource(cfg *types.Config, rule *types.ParameterRule)
 			params = append(params, paramSet)
 		}
 	case "list":
-		for i, item := range rule.Source.Values {
+		for i, item := range src.Values {
 			paramSet := make(map[string]string)
 			paramSet["index"] = fmt.Sprintf("%d", i)
 			for k, v := range item {
@@ -354,29 +510,153 @@ func generateValueParamsFromSource(cfg *types.Config, rule *types.ParameterRule)
 			params = append(params, paramSet)
 		}
 	case "file":
-		path := types.GetRelativeFilePath(rule.Source.File, cfg)
+		path := types.GetRelativeFilePath(src.File, cfg)
 		var err error
-		params, err = parseFileSource(path, rule.Source.Format)
+		params, err = parseFileSource(path, src.Format)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse source file %s: %w", path, err)
 		}
+	case types.ParameterRuleSourceTypeProduct, types.ParameterRuleSourceTypeZip:
+		return combineSources(cfg, ruleName, src)
+	case types.ParameterRuleSourceTypeObjects:
+		return nil, fmt.Errorf("objects source of param_rule %s is generated for each owner", ruleName)
 	default:
-		return nil, fmt.Errorf("unknown source type: %s", rule.Source.Type)
+		return nil, fmt.Errorf("unknown source type: %s", src.Type)
+	}
+
+	return params, nil
+}
+
+// combineSources combines parameter sets of the sub-sources by cartesian product or zip.
+// Keys of each sub-source are prefixed with its param_prefix, and "index" is renumbered for the combination.
+// Conflicting keys between sub-sources are reported as errors.
+func combineSources(cfg *types.Config, ruleName string, src *types.ParameterRuleSource) ([]map[string]string, error) {
+	if len(src.Sources) == 0 {
+		return nil, fmt.Errorf("%s source of param_rule %s has no sources", src.Type, ruleName)
 	}
 
-	// Apply param_format to generate final parameters
-	if rule.ParamFormat != nil && len(rule.ParamFormat) > 0 {
-		for i := range params {
-			for k, tmplVal := range rule.ParamFormat {
-				// Simple variable substitution (TODO: use proper template engine)
-				params[i][k] = tmplVal
+	lists := make([][]map[string]string, 0, len(src.Sources))
+	for _, sub := range src.Sources {
+		params, err := generateParamsFromSource(cfg, ruleName, sub)
+		if err != nil {
+			return nil, err
+		}
+		prefixed := make([]map[string]string, 0, len(params))
+		for _, paramSet := range params {
+			m := make(map[string]string, len(paramSet))
+			for k, v := range paramSet {
+				if sub.ParamPrefix == "" && k == "index" {
+					// renumbered for the combination
+					continue
+				}
+				m[sub.ParamPrefix+k] = v
+			}
+			prefixed = append(prefixed, m)
+		}
+		lists = append(lists, prefixed)
+	}
+
+	var combinations [][]map[string]string
+	switch src.Type {
+	case types.ParameterRuleSourceTypeProduct:
+		combinations = [][]map[string]string{{}}
+		for _, list := range lists {
+			next := make([][]map[string]string, 0, len(combinations)*len(list))
+			for _, comb := range combinations {
+				for _, paramSet := range list {
+					c := make([]map[string]string, len(comb), len(comb)+1)
+					copy(c, comb)
+					next = append(next, append(c, paramSet))
+				}
+			}
+			combinations = next
+		}
+	case types.ParameterRuleSourceTypeZip:
+		for i, list := range lists {
+			if len(list) != len(lists[0]) {
+				return nil, fmt.Errorf("zip source of param_rule %s: sources[%d] has %d items, but sources[0] has %d",
+					ruleName, i, len(list), len(lists[0]))
+			}
+		}
+		for j := range lists[0] {
+			comb := make([]map[string]string, 0, len(lists))
+			for _, list := range lists {
+				comb = append(comb, list[j])
 			}
+			combinations = append(combinations, comb)
 		}
 	}
 
+	params := make([]map[string]string, 0, len(combinations))
+	for i, comb := range combinations {
+		merged := map[string]string{}
+		for _, paramSet := range comb {
+			for k, v := range paramSet {
+				if _, ok := merged[k]; ok {
+					return nil, fmt.Errorf("%s source of param_rule %s: conflicting key %s in sources (use param_prefix)",
+						src.Type, ruleName, k)
+				}
+				merged[k] = v
+			}
+		}
+		if _, ok := merged["index"]; !ok {
+			merged["index"] = fmt.Sprintf("%d", i)
+		}
+		params = append(params, merged)
+	}
 	return params, nil
 }
 
+// ParamFormatOwnerKey is the key of the owner parameters in param_format templates.
+const ParamFormatOwnerKey = "owner"
+
+// applyParamFormat renders param_format templates for each parameter set and returns the formatted copies.
+// Templates can refer to the keys of the source row (e.g., {{ .value }}, {{ .index }} or CSV columns)
+// and the parameters of the owner object as {{ .owner.xxx }}.
+// Missing keys are reported as errors.
+func applyParamFormat(rule *types.ParameterRule, paramSets []map[string]string, owner types.NameSpacer) ([]map[string]string, error) {
+	if len(rule.ParamFormat) == 0 {
+		return paramSets, nil
+	}
+
+	keys := sortedKeys(rule.ParamFormat)
+	templates := make(map[string]*template.Template, len(keys))
+	for _, k := range keys {
+		tpl, err := types.NewTemplate().Option("missingkey=error").Parse(rule.ParamFormat[k])
+		if err != nil {
+			return nil, fmt.Errorf("invalid param_format %s of param_rule %s: %w", k, rule.Name, err)
+		}
+		templates[k] = tpl
+	}
+
+	var ownerParams map[string]string
+	if owner != nil {
+		ownerParams = owner.GetParams()
+	}
+	ret := make([]map[string]string, 0, len(paramSets))
+	for _, paramSet := range paramSets {
+		data := make(map[string]interface{}, len(paramSet)+1)
+		for k, v := range paramSet {
+			data[k] = v
+		}
+		data[ParamFormatOwnerKey] = ownerParams
+
+		formatted := make(map[string]string, len(paramSet)+len(keys))
+		for k, v := range paramSet {
+			formatted[k] = v
+		}
+		for _, k := range keys {
+			buf := &bytes.Buffer{}
+			if err := templates[k].Execute(buf, data); err != nil {
+				return nil, fmt.Errorf("failed to format %s of param_rule %s: %w", k, rule.Name, err)
+			}
+			formatted[k] = buf.String()
+		}
+		ret = append(ret, formatted)
+	}
+	return ret, nil
+}
+
 // generateValueParamsFromGenerator generates parameter sets using a module generator.
 // The generator string is in format "moduleName.generatorName" (e.g., "clab.filemounts")
 func generateValueParamsFromGenerator(cfg *types.Config, nm *types.NetworkModel, rule *types.ParameterRule, target types.ValueOwner) ([]map[string]string, error) {
@@ -416,15 +696,6 @@ func generateValueParamsFromGenerator(cfg *types.Config, nm *types.NetworkModel,
 		return nil, fmt.Errorf("generator %s failed: %w", rule.Generator, err)
 	}
 
-	// Apply param_format to generate final parameters
-	if rule.ParamFormat != nil && len(rule.ParamFormat) > 0 {
-		for i := range params {
-			for k, tmplVal := range rule.ParamFormat {
-				params[i][k] = tmplVal
-			}
-		}
-	}
-
 	return params, nil
 }
 
@@ -471,6 +742,44 @@ func hasFlaggedParam(ns types.NameSpacer, paramName string) bool {
 	return false
 }
 
+// assignComputedParameters evaluates param_rules of type expr with the parameters of flagged objects.
+// Rules are evaluated in the order of param_rule, so later rules can use the values of former rules.
+// Computed values of nodes and connections are also given to their interfaces
+// with node_ and conn_ prefixes in the same way as other parameters.
+func assignComputedParameters(cfg *types.Config, nm *types.NetworkModel) error {
+	for _, rule := range cfg.ParameterRules {
+		if !rule.IsComputed() {
+			continue
+		}
+		tpl, err := rule.ExprTemplate()
+		if err != nil {
+			return err
+		}
+		for _, vo := range nm.ValueOwners() {
+			if !hasFlaggedParam(vo, rule.Name) {
+				continue
+			}
+			buf := &bytes.Buffer{}
+			if err := tpl.Execute(buf, vo.GetParams()); err != nil {
+				return fmt.Errorf("failed to compute %s for %s: %w", rule.Name, vo.StringForMessage(), err)
+			}
+			value := rule.Header + strings.TrimSpace(buf.String()) + rule.Footer
+			vo.AddParam(rule.Name, value)
+
+			switch obj := vo.(type) {
+			case *types.Node:
+				for _, iface := range obj.Interfaces {
+					iface.AddParam(types.NumberPrefixNode+rule.Name, value)
+				}
+			case *types.Connection:
+				obj.Src.AddParam(types.NumberPrefixConnection+rule.Name, value)
+				obj.Dst.AddParam(types.NumberPrefixConnection+rule.Name, value)
+			}
+		}
+	}
+	return nil
+}
+
 // assignAttachModeParameters processes all attach mode param_rules
 func assignAttachModeParameters(cfg *types.Config, nm *types.NetworkModel) error {
 	// Collect all attach mode param_rules and their target objects
@@ -481,10 +790,12 @@ func assignAttachModeParameters(cfg *types.Config, nm *types.NetworkModel) error
 
 		// Determine if this is source-based or generator-based
 		isGenerator := rule.Generator != ""
+		// Objects source depends on the target (e.g., exclude_self and neighbor_layer)
+		isObjects := !isGenerator && rule.Source != nil && rule.Source.Type == types.ParameterRuleSourceTypeObjects
 
 		// For source-based rules, generate once and share across all targets
 		var sharedParamSets []map[string]string
-		if !isGenerator && rule.Source != nil {
+		if !isGenerator && !isObjects && rule.Source != nil {
 			var err error
 			sharedParamSets, err = generateValueParamsFromSource(cfg, rule)
 			if err != nil {
@@ -506,11 +817,23 @@ func assignAttachModeParameters(cfg *types.Config, nm *types.NetworkModel) error
 				if err != nil {
 					return err
 				}
+			} else if isObjects {
+				// Objects source: list objects for each target
+				var err error
+				paramSets, err = generateValueParamsFromObjects(nm, rule, target)
+				if err != nil {
+					return err
+				}
 			} else {
 				// Source: use shared param sets
 				paramSets = sharedParamSets
 			}
 
+			// Apply param_format to generate final parameters for each target
+			paramSets, err := applyParamFormat(rule, paramSets, target)
+			if err != nil {
+				return err
+			}
 			if len(paramSets) > 0 {
 				attachValuesToOwner(target, rule, paramSets)
 			}
@@ -744,8 +1067,8 @@ func generateValueReferenceParams(cfg *types.Config, nm *types.NetworkModel) err
 
 				paramName := "values_" + ct.Name
 
-				if len(values) == 0 {
-					// Set empty string when no Values are attached
+				if len(values) == 0 || !ct.PlatformCheck(cfg.GlobalSettings.Platform) {
+					// Set empty string when no Values are attached (or on non-selected platform)
 					vo.SetRelativeParam(paramName, "")
 					continue
 				}
@@ -780,7 +1103,7 @@ func generateValueConfig(v *types.Value, ct *types.ConfigTemplate) (string, erro
 	if ct.ParsedTemplate == nil {
 		return "", nil
 	}
-	return getConfig(ct.ParsedTemplate, v.GetRelativeParams())
+	return getConfig(ct.ParsedTemplate, configTemplateData(v, ct))
 }
 
 // combineValueConfigs combines multiple config outputs based on FormatStyle.
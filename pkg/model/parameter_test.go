@@ -3,6 +3,11 @@ package model
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/cpflat/dot2net/pkg/types"
@@ -604,6 +609,8 @@ func TestGenerateValuesFromSource_ParamFormat(t *testing.T) {
 		ParamFormat: map[string]string{
 			"vlan_id":   "{{ .value }}",
 			"vlan_name": "VLAN{{ .value }}",
+			"prefix":    "10.{{ .owner.pod }}.{{ .index }}.0/24",
+			"vni":       "{{ add 10000 .value }}",
 		},
 	}
 
@@ -612,26 +619,96 @@ func TestGenerateValuesFromSource_ParamFormat(t *testing.T) {
 		t.Fatalf("generateValueParamsFromSource failed: %v", err)
 	}
 
+	owner := types.NewNetworkModel().NewNode("leaf1")
+	owner.AddParam("pod", "3")
+	params, err = applyParamFormat(rule, params, owner)
+	if err != nil {
+		t.Fatalf("applyParamFormat failed: %v", err)
+	}
+
 	if len(params) != 2 {
 		t.Errorf("expected 2 params, got %d", len(params))
 	}
 
-	// Check that param_format adds new keys
-	// Note: current implementation does simple value assignment, not template expansion
-	// This test documents the current behavior
-	if _, ok := params[0]["vlan_id"]; !ok {
-		t.Errorf("params[0] should have vlan_id key")
+	expected := []map[string]string{
+		{"value": "100", "index": "0", "vlan_id": "100", "vlan_name": "VLAN100", "prefix": "10.3.0.0/24", "vni": "10100"},
+		{"value": "101", "index": "1", "vlan_id": "101", "vlan_name": "VLAN101", "prefix": "10.3.1.0/24", "vni": "10101"},
 	}
-	if _, ok := params[0]["vlan_name"]; !ok {
-		t.Errorf("params[0] should have vlan_name key")
+	if !reflect.DeepEqual(params, expected) {
+		t.Errorf("params = %v, want %v", params, expected)
 	}
 
-	// Original keys should still exist
-	if params[0]["value"] != "100" {
-		t.Errorf("params[0][value] = %q, want %q", params[0]["value"], "100")
+	// missing keys are reported as errors
+	rule.ParamFormat = map[string]string{"prefix": "10.{{ .owner.rack }}.0.0/16"}
+	if _, err := applyParamFormat(rule, params, owner); err == nil {
+		t.Error("expected error for missing owner parameter, got nil")
 	}
-	if params[0]["index"] != "0" {
-		t.Errorf("params[0][index] = %q, want %q", params[0]["index"], "0")
+}
+
+func TestGenerateValuesFromSource_Combine(t *testing.T) {
+	cfg := &types.Config{}
+	vlans := &types.ParameterRuleSource{Type: "range", Start: 100, End: 101, ParamPrefix: "vlan_"}
+	vrfs := &types.ParameterRuleSource{
+		Type:   "list",
+		Values: []map[string]interface{}{{"name": "red"}, {"name": "blue"}},
+	}
+
+	tests := []struct {
+		name     string
+		source   *types.ParameterRuleSource
+		expected []map[string]string
+	}{
+		{
+			name:   "product",
+			source: &types.ParameterRuleSource{Type: "product", Sources: []*types.ParameterRuleSource{vlans, vrfs}},
+			expected: []map[string]string{
+				{"index": "0", "vlan_value": "100", "vlan_index": "0", "name": "red"},
+				{"index": "1", "vlan_value": "100", "vlan_index": "0", "name": "blue"},
+				{"index": "2", "vlan_value": "101", "vlan_index": "1", "name": "red"},
+				{"index": "3", "vlan_value": "101", "vlan_index": "1", "name": "blue"},
+			},
+		},
+		{
+			name:   "zip",
+			source: &types.ParameterRuleSource{Type: "zip", Sources: []*types.ParameterRuleSource{vlans, vrfs}},
+			expected: []map[string]string{
+				{"index": "0", "vlan_value": "100", "vlan_index": "0", "name": "red"},
+				{"index": "1", "vlan_value": "101", "vlan_index": "1", "name": "blue"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := &types.ParameterRule{Name: "combined", Mode: "attach", Source: tt.source}
+			params, err := generateValueParamsFromSource(cfg, rule)
+			if err != nil {
+				t.Fatalf("generateValueParamsFromSource failed: %v", err)
+			}
+			if !reflect.DeepEqual(params, tt.expected) {
+				t.Errorf("params = %v, want %v", params, tt.expected)
+			}
+		})
+	}
+
+	errTests := []struct {
+		name   string
+		source *types.ParameterRuleSource
+	}{
+		{"zip length mismatch", &types.ParameterRuleSource{Type: "zip", Sources: []*types.ParameterRuleSource{
+			vlans, {Type: "range", Start: 1, End: 3},
+		}}},
+		{"conflicting keys", &types.ParameterRuleSource{Type: "product", Sources: []*types.ParameterRuleSource{
+			{Type: "range", Start: 1, End: 2}, {Type: "range", Start: 1, End: 2},
+		}}},
+		{"no sources", &types.ParameterRuleSource{Type: "product"}},
+	}
+	for _, tt := range errTests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := &types.ParameterRule{Name: "combined", Mode: "attach", Source: tt.source}
+			if _, err := generateValueParamsFromSource(cfg, rule); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
 	}
 }
 
@@ -911,3 +988,189 @@ func TestGetParameterCandidates_FileWithEmptyLines(t *testing.T) {
 		}
 	}
 }
+
+func TestGetParameterCandidates_MAC(t *testing.T) {
+	cfg := &types.Config{Name: "lab1"}
+
+	tests := []struct {
+		name     string
+		rule     *types.ParameterRule
+		cnt      int
+		expected []string
+	}{
+		{
+			name:     "colon format from offset 1",
+			rule:     &types.ParameterRule{Name: "mac", Type: "mac", Prefix: "02:42:ac"},
+			cnt:      2,
+			expected: []string{"02:42:ac:00:00:01", "02:42:ac:00:00:02"},
+		},
+		{
+			name:     "cisco format with min",
+			rule:     &types.ParameterRule{Name: "mac", Type: "mac", Prefix: "02:42:ac:10/28", Min: 255, Format: "cisco"},
+			cnt:      2,
+			expected: []string{"0242.ac10.00ff", "0242.ac10.0100"},
+		},
+		{
+			name:     "eui64 link-local",
+			rule:     &types.ParameterRule{Name: "ll", Type: "mac", Prefix: "02:42:ac:11:00", Format: "eui64"},
+			cnt:      1,
+			expected: []string{"fe80::42:acff:fe11:1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, err := getParameterCandidates(cfg, tt.rule, tt.cnt)
+			if err != nil {
+				t.Fatalf("getParameterCandidates failed: %v", err)
+			}
+			if !reflect.DeepEqual(params, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, params)
+			}
+		})
+	}
+
+	t.Run("default prefix by network name", func(t *testing.T) {
+		rule := &types.ParameterRule{Name: "mac", Type: "mac"}
+		params1, err := getParameterCandidates(cfg, rule, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		params2, err := getParameterCandidates(&types.Config{Name: "lab2"}, rule, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(params1[0], "02:") || params1[0] == params2[0] {
+			t.Errorf("default prefixes should be locally administered and differ by network: %v, %v", params1, params2)
+		}
+	})
+
+	t.Run("not enough", func(t *testing.T) {
+		rule := &types.ParameterRule{Name: "mac", Type: "mac", Prefix: "02:42:ac:11:00:00/46"}
+		if _, err := getParameterCandidates(cfg, rule, 4); err == nil {
+			t.Error("expected error when requesting more params than available, got nil")
+		}
+	})
+}
+
+// ============================================================
+// Seeded Rule Tests (random_int, random_string, password, hash)
+// ============================================================
+
+func TestGetSeededParameterCandidates(t *testing.T) {
+	keys := []string{"node:r1", "node:r2", "node:r3", "node:r4"}
+
+	tests := []struct {
+		name  string
+		rule  *types.ParameterRule
+		check func(t *testing.T, value string)
+	}{
+		{
+			name: "random_int",
+			rule: &types.ParameterRule{Name: "rid", Type: "random_int", Min: 1, Max: 65534},
+			check: func(t *testing.T, value string) {
+				n, err := strconv.Atoi(value)
+				if err != nil || n < 1 || n > 65534 {
+					t.Errorf("value %q out of range", value)
+				}
+			},
+		},
+		{
+			name: "random_string",
+			rule: &types.ParameterRule{Name: "id", Type: "random_string", Length: 12, Charset: "abc", Header: "x-"},
+			check: func(t *testing.T, value string) {
+				if !regexp.MustCompile(`^x-[abc]{12}$`).MatchString(value) {
+					t.Errorf("value %q does not match charset", value)
+				}
+			},
+		},
+		{
+			name: "password",
+			rule: &types.ParameterRule{Name: "bgp_password", Type: "password"},
+			check: func(t *testing.T, value string) {
+				if len(value) != DefaultPasswordLength {
+					t.Errorf("password %q length mismatch", value)
+				}
+				for _, cls := range []string{charsetLower, charsetUpper, charsetDigit, charsetSymbol} {
+					if !strings.ContainsAny(value, cls) {
+						t.Errorf("password %q lacks characters of %q", value, cls)
+					}
+				}
+			},
+		},
+		{
+			name: "hash",
+			rule: &types.ParameterRule{Name: "tag", Type: "hash"},
+			check: func(t *testing.T, value string) {
+				if !regexp.MustCompile(`^[0-9a-f]{8}$`).MatchString(value) {
+					t.Errorf("hash %q is not 8 hex digits", value)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &types.Config{GlobalSettings: types.GlobalSettings{Seed: "lab1"}}
+			params, err := getSeededParameterCandidates(cfg, tt.rule, keys)
+			if err != nil {
+				t.Fatalf("getSeededParameterCandidates failed: %v", err)
+			}
+			seen := map[string]bool{}
+			for _, p := range params {
+				tt.check(t, p)
+				if seen[p] {
+					t.Errorf("duplicated value %q", p)
+				}
+				seen[p] = true
+			}
+
+			// reproducible and independent of other objects
+			again, err := getSeededParameterCandidates(cfg, tt.rule, keys[1:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(params[1:], again) {
+				t.Errorf("values not reproducible: %v, %v", params[1:], again)
+			}
+
+			// another seed gives other values
+			cfg.GlobalSettings.Seed = "lab2"
+			other, err := getSeededParameterCandidates(cfg, tt.rule, keys)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reflect.DeepEqual(params, other) {
+				t.Errorf("values not changed by seed: %v", params)
+			}
+		})
+	}
+}
+
+func TestGetSeededParameterCandidates_Unique(t *testing.T) {
+	cfg := &types.Config{}
+	rule := &types.ParameterRule{Name: "prio", Type: "hash", Min: 1, Max: 4}
+	keys := []string{"a", "b", "c", "d"}
+
+	params, err := getSeededParameterCandidates(cfg, rule, keys)
+	if err != nil {
+		t.Fatalf("getSeededParameterCandidates failed: %v", err)
+	}
+	sort.Strings(params)
+	if !reflect.DeepEqual(params, []string{"1", "2", "3", "4"}) {
+		t.Errorf("values should use all candidates: %v", params)
+	}
+
+	_, err = getSeededParameterCandidates(cfg, rule, append(keys, "e"))
+	if err == nil {
+		t.Error("expected error when requesting more params than available, got nil")
+	}
+}
+
+func TestGetSeededParameterCandidates_PasswordWithoutSeed(t *testing.T) {
+	rule := &types.ParameterRule{Name: "bgp_password", Type: "password"}
+	_, err := getSeededParameterCandidates(&types.Config{}, rule, []string{"node:r1"})
+	if err == nil || !strings.Contains(err.Error(), "requires a seed") {
+		t.Errorf("expected seed error, got: %v", err)
+	}
+}
@@ -0,0 +1,345 @@
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/awalterschulze/gographviz"
+
+	"github.com/cpflat/dot2net/pkg/types"
+)
+
+// podTemplateSource keeps the components of a pod template (sub-topology) in a Diagram.
+type podTemplateSource struct {
+	root      string
+	rootAttrs gographviz.Attrs
+	nodes     []*gographviz.Node
+	subgraphs []*gographviz.SubGraph
+	edges     []*gographviz.Edge
+
+	nodeParents     map[string][]string // node name -> parent graph names in the template
+	subgraphParents map[string]string   // subgraph name -> parent graph name in the template
+	nodeGroups      map[string][]string // node name -> group names in the template
+	placeholders    map[string]bool     // attachment point names
+}
+
+// podInstance keeps names of an instance of a pod template.
+type podInstance struct {
+	index   int
+	prefix  string
+	group   string
+	targets map[string][]string // attachment point name -> shared node names
+}
+
+// expandPodTemplates instantiates pod templates defined in the config into the diagram.
+// The template subgraphs are removed from the diagram.
+func expandPodTemplates(cfg *types.Config, d *Diagram) error {
+	if d.podExpanded {
+		return nil
+	}
+	d.podExpanded = true
+
+	for _, pt := range cfg.PodTemplates {
+		src, parents, err := d.loadPodTemplate(cfg, pt)
+		if err != nil {
+			return err
+		}
+		for i := 1; i <= pt.Count; i++ {
+			inst, err := newPodInstance(pt, i)
+			if err != nil {
+				return err
+			}
+			err = d.addPodInstance(pt, src, inst, parents)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func renderPodTemplateString(tpl string, pt *types.PodTemplate, index int) (string, error) {
+	t, err := types.NewTemplate().Option("missingkey=error").Parse(tpl)
+	if err != nil {
+		return "", fmt.Errorf("invalid template %s in pod template %s: %w", tpl, pt.Name, err)
+	}
+	writer := new(bytes.Buffer)
+	err = t.Execute(writer, map[string]interface{}{"name": pt.Name, "index": index})
+	if err != nil {
+		return "", fmt.Errorf("invalid template %s in pod template %s: %w", tpl, pt.Name, err)
+	}
+	return writer.String(), nil
+}
+
+func newPodInstance(pt *types.PodTemplate, index int) (*podInstance, error) {
+	prefix := pt.Prefix
+	if prefix == "" {
+		prefix = types.DefaultPodTemplatePrefix
+	}
+	group := pt.Group
+	if group == "" {
+		group = types.DefaultPodTemplateGroup
+	}
+
+	inst := &podInstance{index: index, targets: map[string][]string{}}
+	var err error
+	inst.prefix, err = renderPodTemplateString(prefix, pt, index)
+	if err != nil {
+		return nil, err
+	}
+	inst.group, err = renderPodTemplateString(group, pt, index)
+	if err != nil {
+		return nil, err
+	}
+	for name, targets := range pt.Attach {
+		for _, target := range targets {
+			t, err := renderPodTemplateString(target, pt, index)
+			if err != nil {
+				return nil, err
+			}
+			inst.targets[name] = append(inst.targets[name], t)
+		}
+	}
+	return inst, nil
+}
+
+// loadPodTemplate collects the components of a pod template.
+// It also returns the parent graph names for instance groups.
+func (d *Diagram) loadPodTemplate(cfg *types.Config, pt *types.PodTemplate) (*podTemplateSource, []string, error) {
+	var tmpl *Diagram
+	var root string
+	var rootAttrs gographviz.Attrs
+	var parents []string
+	if pt.File != "" {
+		var err error
+		tmpl, err = DiagramFromDotFile(types.GetRelativeFilePath(pt.File, cfg))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load pod template %s: %w", pt.Name, err)
+		}
+		root = tmpl.graph.Name
+		rootAttrs = gographviz.Attrs{}
+		parents = []string{d.graph.Name}
+	} else {
+		sub, ok := d.graph.SubGraphs.SubGraphs[pt.SubGraph]
+		if !ok {
+			return nil, nil, fmt.Errorf("subgraph %s for pod template %s not found", pt.SubGraph, pt.Name)
+		}
+		tmpl = d
+		root = sub.Name
+		rootAttrs = sub.Attrs
+		for parent := range d.graph.Relations.ChildToParents[root] {
+			parents = append(parents, parent)
+		}
+	}
+
+	src := &podTemplateSource{
+		root:            root,
+		rootAttrs:       rootAttrs,
+		nodeParents:     map[string][]string{},
+		subgraphParents: map[string]string{},
+		nodeGroups:      map[string][]string{},
+		placeholders:    map[string]bool{},
+	}
+	var walk func(parent string)
+	walk = func(parent string) {
+		for _, child := range tmpl.graph.Relations.SortedChildren(parent) {
+			if sub, ok := tmpl.graph.SubGraphs.SubGraphs[child]; ok {
+				if _, exists := src.subgraphParents[child]; !exists {
+					src.subgraphs = append(src.subgraphs, sub)
+					src.subgraphParents[child] = parent
+					walk(child)
+				}
+			} else if node, ok := tmpl.graph.Nodes.Lookup[child]; ok {
+				if _, exists := src.nodeParents[child]; !exists {
+					src.nodes = append(src.nodes, node)
+				}
+				src.nodeParents[child] = append(src.nodeParents[child], parent)
+			}
+		}
+	}
+	walk(root)
+
+	for _, node := range src.nodes {
+		src.nodeGroups[node.Name] = tmpl.nodeGroups[node.Name]
+	}
+	for name := range pt.Attach {
+		if _, ok := src.nodeParents[name]; !ok {
+			return nil, nil, fmt.Errorf("attachment point %s not found in pod template %s", name, pt.Name)
+		}
+		src.placeholders[name] = true
+	}
+
+	edges := gographviz.NewEdges()
+	for _, e := range tmpl.graph.Edges.Edges {
+		_, srcIn := src.nodeParents[e.Src]
+		_, dstIn := src.nodeParents[e.Dst]
+		if srcIn && dstIn {
+			if src.placeholders[e.Src] && src.placeholders[e.Dst] {
+				return nil, nil, fmt.Errorf("link between attachment points %s and %s in pod template %s",
+					e.Src, e.Dst, pt.Name)
+			}
+			src.edges = append(src.edges, e)
+		} else if srcIn || dstIn {
+			return nil, nil, fmt.Errorf("link %s -- %s crosses the boundary of pod template %s (use attach instead)",
+				e.Src, e.Dst, pt.Name)
+		} else {
+			edges.Add(e)
+		}
+	}
+
+	if tmpl == d {
+		// remove template components from the diagram
+		d.graph.Edges = edges
+		for _, node := range src.nodes {
+			if err := d.graph.Nodes.Remove(node.Name); err != nil {
+				return nil, nil, err
+			}
+			for _, parent := range src.nodeParents[node.Name] {
+				d.graph.Relations.Remove(parent, node.Name)
+			}
+			delete(d.nodeGroups, node.Name)
+		}
+		for _, sub := range src.subgraphs {
+			d.graph.SubGraphs.Remove(sub.Name)
+			delete(d.graph.Relations.ParentToChildren, sub.Name)
+		}
+		d.graph.SubGraphs.Remove(root)
+		for _, parent := range parents {
+			d.graph.Relations.Remove(parent, root)
+		}
+		delete(d.graph.Relations.ParentToChildren, root)
+	}
+
+	return src, parents, nil
+}
+
+// addPodInstance adds an instance of a pod template into the diagram.
+func (d *Diagram) addPodInstance(pt *types.PodTemplate, src *podTemplateSource,
+	inst *podInstance, parents []string) error {
+	indexLabel := pt.GetIndexParam() + types.ValueLabelSeparator + strconv.Itoa(inst.index)
+
+	graphName := func(name string) string {
+		if name == src.root {
+			return inst.group
+		}
+		if _, ok := src.subgraphParents[name]; ok {
+			return inst.prefix + name
+		}
+		return name
+	}
+	nodeNames := func(name string) []string {
+		if src.placeholders[name] {
+			return inst.targets[name]
+		}
+		return []string{inst.prefix + name}
+	}
+
+	// instance group
+	if _, exists := d.graph.SubGraphs.SubGraphs[inst.group]; exists {
+		return fmt.Errorf("group %s of pod template %s conflicts with an existing subgraph", inst.group, pt.Name)
+	}
+	d.graph.SubGraphs.Add(inst.group)
+	groupLabels := append([]string{}, pt.GroupClasses...)
+	groupLabels = append(groupLabels, indexLabel)
+	d.graph.SubGraphs.SubGraphs[inst.group].Attrs = mergeAttrs(src.rootAttrs,
+		gographviz.Attrs{"class": strings.Join(groupLabels, ",")})
+	for _, parent := range parents {
+		d.graph.Relations.Add(parent, inst.group)
+	}
+	parentGroups := d.enclosingGroups(inst.group)
+
+	// nested subgraphs
+	for _, sub := range src.subgraphs {
+		name := graphName(sub.Name)
+		if _, exists := d.graph.SubGraphs.SubGraphs[name]; exists {
+			return fmt.Errorf("subgraph %s of pod template %s conflicts with an existing subgraph", name, pt.Name)
+		}
+		d.graph.SubGraphs.Add(name)
+		d.graph.SubGraphs.SubGraphs[name].Attrs = sub.Attrs.Copy()
+		d.graph.Relations.Add(graphName(src.subgraphParents[sub.Name]), name)
+	}
+
+	// nodes
+	for _, node := range src.nodes {
+		if src.placeholders[node.Name] {
+			continue
+		}
+		name := inst.prefix + node.Name
+		if _, exists := d.graph.Nodes.Lookup[name]; exists {
+			return fmt.Errorf("node %s of pod template %s conflicts with an existing node", name, pt.Name)
+		}
+		attrs := mergeAttrs(node.Attrs, gographviz.Attrs{"class": indexLabel})
+		d.graph.Nodes.Add(&gographviz.Node{Name: name, Attrs: attrs})
+		for _, parent := range src.nodeParents[node.Name] {
+			d.graph.Relations.Add(graphName(parent), name)
+		}
+
+		// groups are ordered from the innermost one, as in searchGroupMembers
+		groups := []string{}
+		names := []string{}
+		for _, group := range src.nodeGroups[node.Name] {
+			names = append(names, graphName(group))
+		}
+		names = append(names, inst.group)
+		names = append(names, parentGroups...)
+		for _, group := range names {
+			if !slices.Contains(groups, group) {
+				groups = append(groups, group)
+			}
+		}
+		d.nodeGroups[name] = groups
+	}
+
+	// attachment targets
+	for _, targets := range inst.targets {
+		for _, target := range targets {
+			if _, exists := d.graph.Nodes.Lookup[target]; !exists {
+				return fmt.Errorf("attachment target node %s of pod template %s not found", target, pt.Name)
+			}
+		}
+	}
+
+	// links
+	for _, e := range src.edges {
+		for _, srcName := range nodeNames(e.Src) {
+			for _, dstName := range nodeNames(e.Dst) {
+				d.graph.Edges.Add(&gographviz.Edge{
+					Src: srcName, SrcPort: e.SrcPort, Dst: dstName, DstPort: e.DstPort,
+					Dir: e.Dir, Attrs: e.Attrs.Copy(),
+				})
+			}
+		}
+	}
+
+	return nil
+}
+
+// enclosingGroups returns the names of the groups (subgraphs) enclosing the given graph,
+// ordered from the innermost one.
+func (d *Diagram) enclosingGroups(name string) []string {
+	var groups []string
+	seen := map[string]bool{}
+	queue := []string{name}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		var parents []string
+		for parent := range d.graph.Relations.ChildToParents[current] {
+			parents = append(parents, parent)
+		}
+		sort.Strings(parents)
+		for _, parent := range parents {
+			if parent == d.graph.Name || seen[parent] {
+				continue
+			}
+			seen[parent] = true
+			groups = append(groups, parent)
+			queue = append(queue, parent)
+		}
+	}
+	return groups
+}
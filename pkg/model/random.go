@@ -0,0 +1,207 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/cpflat/dot2net/pkg/types"
+)
+
+const charsetLower = "abcdefghijklmnopqrstuvwxyz"
+const charsetUpper = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+const charsetDigit = "0123456789"
+
+// charsetSymbol avoids characters with special meanings in config files and shells (e.g., quotes, $, #, !)
+const charsetSymbol = "-_.+=@%~"
+
+const DefaultRandomStringLength = 16
+const DefaultPasswordLength = 20
+const DefaultHashLength = 8
+
+// maxSeededAttempts is the number of retries to avoid duplicated values in a param_rule.
+const maxSeededAttempts = 1000
+
+// seededStream is a deterministic byte stream derived from the seed, param_rule name, object and attempt.
+// It is SHA-256 in counter mode, so the output is reproducible across builds and platforms.
+type seededStream struct {
+	base    []byte
+	counter uint64
+	buf     []byte
+}
+
+func newSeededStream(seed, rule, key string, attempt int) *seededStream {
+	h := sha256.New()
+	for _, s := range []string{seed, rule, key} {
+		// length-prefixed to avoid ambiguity of concatenation
+		_ = binary.Write(h, binary.BigEndian, uint32(len(s)))
+		h.Write([]byte(s))
+	}
+	_ = binary.Write(h, binary.BigEndian, uint32(attempt))
+	return &seededStream{base: h.Sum(nil)}
+}
+
+func (s *seededStream) next() byte {
+	if len(s.buf) == 0 {
+		h := sha256.New()
+		h.Write(s.base)
+		_ = binary.Write(h, binary.BigEndian, s.counter)
+		s.counter++
+		s.buf = h.Sum(nil)
+	}
+	b := s.buf[0]
+	s.buf = s.buf[1:]
+	return b
+}
+
+func (s *seededStream) uint64() uint64 {
+	var v uint64
+	for i := 0; i < 8; i++ {
+		v = v<<8 | uint64(s.next())
+	}
+	return v
+}
+
+// intn returns an unbiased integer in [0, n) using rejection sampling.
+func (s *seededStream) intn(n uint64) uint64 {
+	limit := ^uint64(0) - (^uint64(0) % n)
+	for {
+		if v := s.uint64(); v < limit {
+			return v % n
+		}
+	}
+}
+
+func (s *seededStream) stringOf(charset string, length int) string {
+	var sb strings.Builder
+	for i := 0; i < length; i++ {
+		sb.WriteByte(charset[s.intn(uint64(len(charset)))])
+	}
+	return sb.String()
+}
+
+// seededValue generates a value of the seeded param_rule for the object key.
+func seededValue(seed string, rule *types.ParameterRule, key string, attempt int) string {
+	s := newSeededStream(seed, rule.Name, key, attempt)
+	var value string
+	switch rule.Type {
+	case types.ParameterRuleTypeRandomInt:
+		value = fmt.Sprint(rule.Min + int(s.intn(uint64(rule.Max-rule.Min)+1)))
+	case types.ParameterRuleTypeRandomString:
+		length := rule.Length
+		if length == 0 {
+			length = DefaultRandomStringLength
+		}
+		charset := rule.Charset
+		if charset == "" {
+			charset = charsetLower + charsetUpper + charsetDigit
+		}
+		value = s.stringOf(charset, length)
+	case types.ParameterRuleTypePassword:
+		length := rule.Length
+		if length == 0 {
+			length = DefaultPasswordLength
+		}
+		value = passwordOf(s, rule.Charset, length)
+	case types.ParameterRuleTypeHash:
+		if rule.Max != 0 {
+			value = fmt.Sprint(rule.Min + int(s.intn(uint64(rule.Max-rule.Min)+1)))
+		} else {
+			length := rule.Length
+			if length == 0 {
+				length = DefaultHashLength
+			}
+			digest := hex.EncodeToString(s.base)
+			if length < len(digest) {
+				digest = digest[:length]
+			}
+			value = digest
+		}
+	}
+	return rule.Header + value + rule.Footer
+}
+
+// passwordOf generates a password including at least one character of each class
+// (lower, upper, digit and symbol) if the length allows.
+// If charset is given, the characters are chosen from the charset without the class requirement.
+func passwordOf(s *seededStream, charset string, length int) string {
+	if charset != "" {
+		return s.stringOf(charset, length)
+	}
+	classes := []string{charsetLower, charsetUpper, charsetDigit, charsetSymbol}
+	for {
+		pw := s.stringOf(strings.Join(classes, ""), length)
+		if length < len(classes) {
+			return pw
+		}
+		satisfied := true
+		for _, cls := range classes {
+			if !strings.ContainsAny(pw, cls) {
+				satisfied = false
+				break
+			}
+		}
+		if satisfied {
+			return pw
+		}
+	}
+}
+
+// getSeededParameterCandidates generates values of the seeded param_rule for the objects of the keys.
+// A value depends only on the seed, param_rule name and object key,
+// so adding or removing other objects does not change the value (except for resolving duplicates).
+// Duplicated values are avoided by regenerating with the next attempt number in the order of keys.
+// Passwords require a seed, because values without seed can be computed from the topology and config files.
+func getSeededParameterCandidates(cfg *types.Config, rule *types.ParameterRule, keys []string) ([]string, error) {
+	if rule.Type == types.ParameterRuleTypePassword && cfg.GlobalSettings.Seed == "" {
+		return nil, fmt.Errorf("param_rule %s of type password requires a seed (global.seed or --seed)", rule.Name)
+	}
+	if rule.Type == types.ParameterRuleTypeRandomInt || (rule.Type == types.ParameterRuleTypeHash && rule.Max != 0) {
+		if rule.Max-rule.Min+1 < len(keys) {
+			return nil, fmt.Errorf("not enough candidates for %s (%d required)", rule.Name, len(keys))
+		}
+	}
+
+	used := map[string]struct{}{}
+	params := make([]string, 0, len(keys))
+	for _, key := range keys {
+		found := false
+		for attempt := 0; attempt < maxSeededAttempts; attempt++ {
+			value := seededValue(cfg.GlobalSettings.Seed, rule, key, attempt)
+			if _, ok := used[value]; !ok {
+				used[value] = struct{}{}
+				params = append(params, value)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("failed to generate a unique value of %s for %s", rule.Name, key)
+		}
+	}
+	return params, nil
+}
+
+// seededKeyOf returns the object key for seeded param_rules.
+func seededKeyOf(obj types.NameSpacer) string {
+	if seg, ok := obj.(*types.NetworkSegment); ok {
+		// StringForMessage of segments includes the number of members
+		return fmt.Sprintf("segment:%s:%s", seg.Layer, seg.Name)
+	}
+	return obj.StringForMessage()
+}
+
+// getObjectParameterCandidates returns parameter candidates for the objects.
+// Seeded param_rules generate values by objects, and others by the number of objects.
+func getObjectParameterCandidates[T types.NameSpacer](cfg *types.Config, rule *types.ParameterRule, objects []T) ([]string, error) {
+	if !rule.IsSeeded() {
+		return getParameterCandidates(cfg, rule, len(objects))
+	}
+	keys := make([]string, 0, len(objects))
+	for _, obj := range objects {
+		keys = append(keys, seededKeyOf(obj))
+	}
+	return getSeededParameterCandidates(cfg, rule, keys)
+}
@@ -2,8 +2,11 @@ package types
 
 import (
 	"fmt"
+	"net/netip"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
 	"strings"
 	"text/template"
 
@@ -78,6 +81,20 @@ const IPAddressReplacerFooter string = "addr"
 const IPNetworkReplacerFooter string = "net"
 const IPProtocolReplacerFooter string = "protocol"
 const IPPrefixLengthReplacerFooter string = "plen"
+const IPVIPReplacerFooter string = "vip"   // segment VIP given to member interfaces
+const IPVRIDReplacerFooter string = "vrid" // VRRP group ID of the segment VIP given to member interfaces
+
+// Group sub-range replacer of IP policies: [IPPolicy]_[GroupRangeReplacerFooter]
+const GroupRangeReplacerFooter string = "range"
+
+// Segment VIP (IPPolicy.VIP): reserved address position and parameters
+const IPPolicyVIPFirst string = "first"
+const IPPolicyVIPLast string = "last"
+const DefaultVRID int = 1
+const SegmentVIPParam string = "segment_vip"   // given to member interfaces (of the first layer with vip)
+const SegmentVRIDParam string = "segment_vrid" // given to member interfaces (of the first layer with vip)
+const SegmentVIPKey string = "vip"             // given to segments
+const SegmentVRIDKey string = "vrid"           // given to segments
 
 const IPPolicyTypeDefault string = "ip"
 const IPPolicyTypeLoopback string = "loopback"
@@ -90,6 +107,16 @@ func AllOutput() []string {
 	return []string{OutputTinet, OutputClab, OutputAsis}
 }
 
+// checkPlatform returns an error if the name is not a valid platform.
+func checkPlatform(name string) error {
+	for _, output := range AllOutput() {
+		if name == output {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown platform %s (available: %s)", name, strings.Join(AllOutput(), ", "))
+}
+
 // config elements
 
 type Config struct {
@@ -101,6 +128,11 @@ type Config struct {
 	Layers          []*Layer          `yaml:"layer" mapstructure:"layer"`
 	ManagementLayer ManagementLayer   `yaml:"mgmt_layer" mapstructure:"mgmt_layer"`
 	ParameterRules  []*ParameterRule  `yaml:"param_rule,flow" mapstructure:"param_rule,flow"`
+	PodTemplates    []*PodTemplate    `yaml:"podtemplate,flow" mapstructure:"podtemplate,flow"`
+	ClassifyRules   []*ClassifyRule   `yaml:"classify,flow" mapstructure:"classify,flow"`
+	TemplateLibrary TemplateLibrary   `yaml:"template_library" mapstructure:"template_library"`
+	// ObjectValuesFile is a YAML file of per-object values (keyed by node, node:interface, connection and group names)
+	ObjectValuesFile string `yaml:"object_values" mapstructure:"object_values"`
 
 	NetworkClasses    []*NetworkClass    `yaml:"networkclass,flow" mapstructure:"network,flow"`
 	NodeClasses       []*NodeClass       `yaml:"nodeclass,flow" mapstructure:"nodes,flow"`
@@ -121,8 +153,10 @@ type Config struct {
 	groupClassMap      map[string]*GroupClass
 	segmentClassMap    map[string]*SegmentClass
 	neighborClassMap   map[string]map[string][]*NeighborClass // interfaceclass name, ipspace name
+	templateLibrary    *template.Template
 	localDir           string
 
+	ObjectValues               *ObjectValues      // loaded from ObjectValuesFile, internal
 	LoadedModules              []Module           // reference to loaded modules, internal
 	SorterConfigTemplateGroups mapset.Set[string] // list of sort-style config template groups
 }
@@ -187,6 +221,13 @@ func (cfg *Config) DefaultConnectionLayer() []string {
 	return layers
 }
 
+// isValueLabel returns true if the label is a value label (e.g., desc=see #1),
+// even if the value includes RelationalClassLabelSeparator.
+func isValueLabel(label string) bool {
+	idx := strings.Index(label, ValueLabelSeparator)
+	return idx >= 0 && idx < strings.Index(label, RelationalClassLabelSeparator)
+}
+
 func (cfg *Config) classifyLabels(given []string) *ParsedLabels {
 	pl := newParsedLabels()
 	for _, label := range given {
@@ -204,7 +245,7 @@ func (cfg *Config) classifyLabels(given []string) *ParsedLabels {
 				pl.placeLabels = append(pl.placeLabels, plabel)
 			}
 		} else {
-			if strings.Contains(label, RelationalClassLabelSeparator) {
+			if strings.Contains(label, RelationalClassLabelSeparator) && !isValueLabel(label) {
 				// include "#" -> RelationalClassLabel
 				sep := strings.SplitN(label, RelationalClassLabelSeparator, 2)
 				rlabel := RelationalClassLabel{ClassType: sep[0], Name: sep[1]}
@@ -243,6 +284,7 @@ func (cfg *Config) getValidClasses(given []string, hasAll bool, hasDefault bool)
 	if len(classLabels) == 0 {
 		if hasDefault {
 			classes = append(classes, ClassDefault)
+			pl.defaultClass = true
 		}
 	} else {
 		classes = append(classes, classLabels...)
@@ -332,6 +374,69 @@ type GlobalSettings struct {
 	PathSpecification string `yaml:"path" mapstructure:"path"`
 	MountSourcePath   string `yaml:"mountsourcepath" mapstructure:"mountsourcepath"`
 	NodeAutoRename    bool   `yaml:"nodeautoname" mapstructure:"nodeautoname"`
+	// DotAttributes exposes all DOT attributes of nodes, links and subgraphs
+	// as parameters with prefix "dot_attr_" (e.g., dot_attr_color)
+	DotAttributes bool `yaml:"dot_attributes" mapstructure:"dot_attributes"`
+	// Platform selects the target platform (tinet, clab or command).
+	// Config templates and modules for other platforms are skipped. All platforms in default.
+	Platform string `yaml:"platform" mapstructure:"platform"`
+	// ValuePrecedence orders the sources of given values from the highest priority
+	// (label, object and class). Default is [label, object, class].
+	ValuePrecedence []string `yaml:"value_precedence,flow" mapstructure:"value_precedence,flow"`
+	// ValueConflict specifies how to handle given values conflicting in the same level of ValuePrecedence
+	// (e.g., between classes) (warn, error or ignore). Default is warn.
+	// Values overridden by a higher level (e.g., class values overridden by DOT labels) are not reported.
+	ValueConflict string `yaml:"value_conflict" mapstructure:"value_conflict"`
+	// Seed is the seed of random and hash param_rule types (random_int, random_string, password and hash).
+	// The same seed reproduces the same parameters. Values without seed can be computed by anyone
+	// with the topology and config files, so password requires a seed (kept out of shared files, e.g., by --seed).
+	Seed string `yaml:"seed" mapstructure:"seed"`
+	// IPAMLock is the path of the IPAM lock file to record assigned addresses.
+	// Recorded addresses are reused on the next build, so that topology edits do not renumber existing objects.
+	IPAMLock string `yaml:"ipam_lock" mapstructure:"ipam_lock"`
+}
+
+const ValueSourceLabel string = "label"
+const ValueSourceObject string = "object"
+const ValueSourceClass string = "class"
+
+const ValueConflictWarn string = "warn"
+const ValueConflictError string = "error"
+const ValueConflictIgnore string = "ignore"
+
+// GetValuePrecedence returns the sources of given values from the highest priority.
+// Sources not specified in ValuePrecedence follow in the default order.
+func (gs *GlobalSettings) GetValuePrecedence() []string {
+	return mergeStringLists(gs.ValuePrecedence, []string{ValueSourceLabel, ValueSourceObject, ValueSourceClass})
+}
+
+// GetValueConflict returns how to handle conflicting given values.
+func (gs *GlobalSettings) GetValueConflict() string {
+	if gs.ValueConflict == "" {
+		return ValueConflictWarn
+	}
+	return gs.ValueConflict
+}
+
+func (gs *GlobalSettings) validate() error {
+	if gs.Platform != "" {
+		if err := checkPlatform(gs.Platform); err != nil {
+			return err
+		}
+	}
+	for _, src := range gs.ValuePrecedence {
+		switch src {
+		case ValueSourceLabel, ValueSourceObject, ValueSourceClass:
+		default:
+			return fmt.Errorf("unknown value source %s in value_precedence", src)
+		}
+	}
+	switch gs.GetValueConflict() {
+	case ValueConflictWarn, ValueConflictError, ValueConflictIgnore:
+	default:
+		return fmt.Errorf("unknown value_conflict %s", gs.ValueConflict)
+	}
+	return nil
 }
 
 type FileDefinition struct {
@@ -497,6 +602,14 @@ func (layer *Layer) IPLoopbackReplacer() string {
 	return layer.Name + "_" + IPLoopbackReplacerFooter
 }
 
+func (layer *Layer) IPVIPReplacer() string {
+	return layer.Name + "_" + IPVIPReplacerFooter
+}
+
+func (layer *Layer) IPVRIDReplacer() string {
+	return layer.Name + "_" + IPVRIDReplacerFooter
+}
+
 type ManagementLayer struct {
 	Name      string `yaml:"name" mapstructure:"name"`
 	AddrRange string `yaml:"range" mapstructure:"range"`
@@ -526,10 +639,79 @@ type IPPolicy struct {
 	Type                string `yaml:"type" mapstructure:"type"`
 	AddrRange           string `yaml:"range" mapstructure:"range"`
 	DefaultPrefixLength int    `yaml:"prefix" mapstructure:"prefix"`
+	// PointToPoint uses both addresses of /31 (RFC 3021) or /127 (RFC 6164) prefixes
+	// for segments with two interfaces, without skipping network and broadcast addresses.
+	PointToPoint bool `yaml:"p2p" mapstructure:"p2p"`
+	// GroupPrefix carves a sub-range of the prefix length per group (e.g., 20 for a /20 per pod).
+	// Segments and loopbacks of the group members are allocated from the sub-range of the group,
+	// and objects out of the groups (e.g., links between pods) from another sub-range placed after them.
+	GroupPrefix int `yaml:"group_prefix" mapstructure:"group_prefix"`
+	// GroupClass selects the group with the class for sub-ranges (default: the innermost group)
+	GroupClass string `yaml:"group_class" mapstructure:"group_class"`
+	// VIP reserves the first or last host address of each segment as a gateway or virtual IP (first or last).
+	// The address is given to member interfaces as <layer>_vip, and excluded from the interface addresses.
+	// segment_vip is also given for the first layer with vip (e.g., the VIP of the only layer with vip).
+	VIP string `yaml:"vip" mapstructure:"vip"`
+	// VRID is the VRRP group ID given to member interfaces as <layer>_vrid (and segment_vrid) with VIP (default: 1).
+	VRID int `yaml:"vrid" mapstructure:"vrid"`
 
 	layer *Layer
 }
 
+// GetVRID returns the VRRP group ID of the segments with VIP.
+func (policy *IPPolicy) GetVRID() int {
+	if policy.VRID == 0 {
+		return DefaultVRID
+	}
+	return policy.VRID
+}
+
+// GroupRangeReplacer returns the parameter name of the sub-range given to groups (group_<policy>_range for members).
+func (policy *IPPolicy) GroupRangeReplacer() string {
+	return policy.Name + "_" + GroupRangeReplacerFooter
+}
+
+// IsPointToPointPrefix returns true if the prefix is /31 (IPv4) or /127 (IPv6).
+func IsPointToPointPrefix(prefix netip.Prefix) bool {
+	return prefix.Bits() == prefix.Addr().BitLen()-1
+}
+
+func (policy *IPPolicy) validate() error {
+	switch policy.VIP {
+	case "", IPPolicyVIPFirst, IPPolicyVIPLast:
+	default:
+		return fmt.Errorf("unknown vip %s of policy %s (first or last)", policy.VIP, policy.Name)
+	}
+	if policy.VRID < 0 || policy.VRID > 255 {
+		return fmt.Errorf("vrid %d of policy %s must be in 1-255", policy.VRID, policy.Name)
+	}
+	if policy.VRID != 0 && policy.VIP == "" {
+		return fmt.Errorf("vrid of policy %s requires vip", policy.Name)
+	}
+	if policy.VIP != "" && (policy.PointToPoint || policy.Type == IPPolicyTypeLoopback) {
+		return fmt.Errorf("vip of policy %s is not available with p2p or loopback policies", policy.Name)
+	}
+	if !policy.PointToPoint && policy.GroupPrefix == 0 {
+		return nil
+	}
+	poolrange, err := netip.ParsePrefix(policy.AddrRange)
+	if err != nil {
+		return fmt.Errorf("invalid range (%v) for policy (%v)", policy.AddrRange, policy.Name)
+	}
+	if policy.PointToPoint && policy.DefaultPrefixLength != poolrange.Addr().BitLen()-1 {
+		return fmt.Errorf("p2p policy %s requires prefix 31 (IPv4) or 127 (IPv6)", policy.Name)
+	}
+	if policy.GroupPrefix != 0 {
+		if policy.GroupPrefix <= poolrange.Bits() || policy.GroupPrefix > poolrange.Addr().BitLen() {
+			return fmt.Errorf("group_prefix %d of policy %s must be longer than the range %s", policy.GroupPrefix, policy.Name, policy.AddrRange)
+		}
+		if policy.Type != IPPolicyTypeLoopback && policy.GroupPrefix > policy.DefaultPrefixLength {
+			return fmt.Errorf("group_prefix %d of policy %s must not be longer than the prefix %d", policy.GroupPrefix, policy.Name, policy.DefaultPrefixLength)
+		}
+	}
+	return nil
+}
+
 // ParameterRuleMode constants
 const (
 	// ParameterRuleModeDistribute distributes one parameter per object (default, legacy behavior)
@@ -538,9 +720,38 @@ const (
 	ParameterRuleModeAttach = "attach"
 )
 
+// ParameterRule types generating seeded values per object
+const (
+	// ParameterRuleTypeRandomInt generates integers in [min, max]
+	ParameterRuleTypeRandomInt = "random_int"
+	// ParameterRuleTypeRandomString generates strings of the charset
+	ParameterRuleTypeRandomString = "random_string"
+	// ParameterRuleTypePassword generates strings including lower, upper, digit and symbol characters
+	ParameterRuleTypePassword = "password"
+	// ParameterRuleTypeHash generates hex digests (or integers in [min, max] if max is given)
+	ParameterRuleTypeHash = "hash"
+)
+
+// ParameterRule assign scopes
+const (
+	ParameterRuleAssignObject     = "object"
+	ParameterRuleAssignSegment    = "segment"
+	ParameterRuleAssignConnection = "connection"
+	// ParameterRuleAssignGroup restarts numbering in each group
+	ParameterRuleAssignGroup = "group"
+	// ParameterRuleAssignNode restarts numbering of interfaces in each node
+	ParameterRuleAssignNode = "node"
+)
+
+// ParameterRuleTypeMAC generates MAC addresses sequentially in the prefix
+const ParameterRuleTypeMAC = "mac"
+
+// ParameterRuleTypeExpr computes values from other parameters of the object after assignment
+const ParameterRuleTypeExpr = "expr"
+
 // ParameterRuleSource defines the source for generating Value lists
 type ParameterRuleSource struct {
-	// Type specifies the source type: range, sequence, list, file
+	// Type specifies the source type: range, sequence, list, file, objects, product or zip
 	Type string `yaml:"type" mapstructure:"type"`
 	// Start is used for range type
 	Start int `yaml:"start" mapstructure:"start"`
@@ -552,6 +763,89 @@ type ParameterRuleSource struct {
 	File string `yaml:"file" mapstructure:"file"`
 	// Format specifies the file format: yaml, json, csv, text (default: auto-detect from extension)
 	Format string `yaml:"format" mapstructure:"format"`
+
+	// === objects type fields ===
+	// Object specifies the object type: node, interface, connection, group or segment
+	Object string `yaml:"object" mapstructure:"object"`
+	// Class selects objects with the class
+	Class string `yaml:"class" mapstructure:"class"`
+	// Group selects nodes (or interfaces of nodes) in the group of the name or class
+	Group string `yaml:"group" mapstructure:"group"`
+	// Layer selects segments of the layer (for object segment)
+	Layer string `yaml:"layer" mapstructure:"layer"`
+	// NeighborLayer selects nodes or interfaces adjacent to the owner in the layer
+	NeighborLayer string `yaml:"neighbor_layer" mapstructure:"neighbor_layer"`
+	// ExcludeSelf excludes the owner (and the node of the owner interface) from the objects
+	ExcludeSelf bool `yaml:"exclude_self" mapstructure:"exclude_self"`
+	// Filter is a template evaluated with the object parameters (and {{ .owner.xxx }}),
+	// and selects objects rendered as "true"
+	Filter string `yaml:"filter" mapstructure:"filter"`
+	// SortBy sorts objects by the parameter (numerically if possible). Default is the order in the network model.
+	SortBy string `yaml:"sort_by" mapstructure:"sort_by"`
+	// ParamPrefix is the prefix of the object parameters in the Value namespace (default: "obj_"),
+	// or the prefix of the keys of a sub-source in product or zip sources
+	ParamPrefix string `yaml:"param_prefix" mapstructure:"param_prefix"`
+
+	// Sources are the sub-sources combined by product or zip type
+	Sources []*ParameterRuleSource `yaml:"sources,flow" mapstructure:"sources,flow"`
+}
+
+// ParameterRuleSource types of objects and combined sources
+const (
+	ParameterRuleSourceTypeObjects string = "objects"
+	// ParameterRuleSourceTypeProduct combines sub-sources by cartesian product (e.g., VLANs x VRFs)
+	ParameterRuleSourceTypeProduct string = "product"
+	// ParameterRuleSourceTypeZip combines sub-sources item by item (e.g., tenants with VNIs)
+	ParameterRuleSourceTypeZip string = "zip"
+)
+
+const DefaultObjectsSourceParamPrefix string = "obj_"
+
+// GetParamPrefix returns the prefix of the object parameters for the objects source.
+func (src *ParameterRuleSource) GetParamPrefix() string {
+	if src.ParamPrefix == "" {
+		return DefaultObjectsSourceParamPrefix
+	}
+	return src.ParamPrefix
+}
+
+func (src *ParameterRuleSource) validate() error {
+	switch src.Type {
+	case ParameterRuleSourceTypeProduct, ParameterRuleSourceTypeZip:
+		if len(src.Sources) == 0 {
+			return fmt.Errorf("sources are required for %s source", src.Type)
+		}
+		for i, sub := range src.Sources {
+			if sub.Type == ParameterRuleSourceTypeObjects {
+				return fmt.Errorf("sources[%d]: objects source cannot be combined", i)
+			}
+			if err := sub.validate(); err != nil {
+				return fmt.Errorf("sources[%d]: %w", i, err)
+			}
+		}
+		return nil
+	case ParameterRuleSourceTypeObjects:
+	default:
+		return nil
+	}
+	switch src.Object {
+	case ClassTypeNode, ClassTypeInterface:
+	case ClassTypeConnection, ClassTypeGroup, ClassTypeSegment:
+		if src.NeighborLayer != "" {
+			return fmt.Errorf("neighbor_layer is available only for node and interface objects")
+		}
+		if src.Group != "" && src.Object != ClassTypeGroup {
+			return fmt.Errorf("group is available only for node, interface and group objects")
+		}
+	default:
+		return fmt.Errorf("unknown object type %q in objects source", src.Object)
+	}
+	if src.Filter != "" {
+		if _, err := NewTemplate().Parse(src.Filter); err != nil {
+			return fmt.Errorf("invalid filter: %w", err)
+		}
+	}
+	return nil
 }
 
 type ParameterRule struct {
@@ -560,8 +854,15 @@ type ParameterRule struct {
 	// distribute: N objects get 1 parameter each (legacy behavior)
 	// attach: 1 object gets N Values attached
 	Mode string `yaml:"mode" mapstructure:"mode"`
-	// object (in default) or segment
+	// object (in default), segment, connection, group or node
+	// group: numbering restarts in each group (of nodes or interfaces)
+	// node: numbering restarts in each node (of interfaces)
 	Assign string `yaml:"assign" mapstructure:"assign"`
+	// GroupClass selects the group with the class for assign: group (default: the innermost group)
+	GroupClass string `yaml:"group_class" mapstructure:"group_class"`
+	// Offset is a template of the numbering offset in each group or node for assign: group or node,
+	// evaluated with the parameters of the group or node (e.g., "{{ mul (sub .pod_index 1) 100 }}")
+	Offset string `yaml:"offset" mapstructure:"offset"`
 	// layer is used only when the assign option is "segment"
 	Layer string `yaml:"layer" mapstructure:"layer"`
 	// integer (in default) or file
@@ -573,13 +874,27 @@ type ParameterRule struct {
 	Footer string `yaml:"footer" mapstructure:"footer"`
 	// for type file
 	SourceFile string `yaml:"sourcefile" mapstructure:"soucefile"`
+	// for type random_string, password and hash
+	Length  int    `yaml:"length" mapstructure:"length"`
+	Charset string `yaml:"charset" mapstructure:"charset"`
+	// for type mac: prefix (e.g., 02:42:ac or 02:42:ac:10/28) and format (colon, dash, cisco or eui64).
+	// min and max are the offsets in the prefix. Default prefix is a locally administered /24 derived from the network name.
+	Prefix string `yaml:"prefix" mapstructure:"prefix"`
+	Format string `yaml:"format" mapstructure:"format"`
+	// for type expr: a template evaluated with the parameters of the object after assignment
+	// (e.g., "{{ add 65000 .router_id }}")
+	Expr string `yaml:"expr" mapstructure:"expr"`
+
+	exprTemplate *template.Template
 
 	// === attach mode fields ===
 	// Source defines how to generate Value list (for attach mode)
 	Source *ParameterRuleSource `yaml:"source" mapstructure:"source"`
 	// Generator specifies a module-provided generator (e.g., "clab.bindmounts")
 	Generator string `yaml:"generator" mapstructure:"generator"`
-	// ParamFormat defines how to format source values into Value parameters
+	// ParamFormat defines how to format source values into Value parameters.
+	// Each entry is a template over the source row (e.g., {{ .value }}, {{ .index }}, CSV columns)
+	// and the owner parameters (e.g., {{ .owner.pod }}).
 	ParamFormat map[string]string `yaml:"param_format" mapstructure:"param_format"`
 	// ConfigTemplates defines config blocks for Values
 	ConfigTemplates []*ConfigTemplate `yaml:"config,flow" mapstructure:"config,flow"`
@@ -600,6 +915,200 @@ func (pr *ParameterRule) IsAttachMode() bool {
 	return pr.GetMode() == ParameterRuleModeAttach
 }
 
+// IsComputed returns true if this rule computes values from other parameters (type expr).
+func (pr *ParameterRule) IsComputed() bool {
+	return pr.Type == ParameterRuleTypeExpr
+}
+
+// ExprTemplate returns the parsed template of type expr.
+// Missing parameters in the template are reported as errors.
+func (pr *ParameterRule) ExprTemplate() (*template.Template, error) {
+	if pr.exprTemplate == nil {
+		tpl, err := NewTemplate().Option("missingkey=error").Parse(pr.Expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expr of parameter rule %s: %w", pr.Name, err)
+		}
+		pr.exprTemplate = tpl
+	}
+	return pr.exprTemplate, nil
+}
+
+// IsSeeded returns true if this rule generates seeded values per object
+// (random_int, random_string, password and hash).
+func (pr *ParameterRule) IsSeeded() bool {
+	switch pr.Type {
+	case ParameterRuleTypeRandomInt, ParameterRuleTypeRandomString, ParameterRuleTypePassword, ParameterRuleTypeHash:
+		return true
+	default:
+		return false
+	}
+}
+
+func (pr *ParameterRule) validate() error {
+	switch pr.Assign {
+	case "", ParameterRuleAssignObject, ParameterRuleAssignSegment, ParameterRuleAssignConnection:
+	case ParameterRuleAssignGroup, ParameterRuleAssignNode:
+		if pr.Offset != "" {
+			if _, err := NewTemplate().Parse(pr.Offset); err != nil {
+				return fmt.Errorf("invalid offset: %w", err)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown assign %s", pr.Assign)
+	}
+	if pr.Offset != "" && pr.Assign != ParameterRuleAssignGroup && pr.Assign != ParameterRuleAssignNode {
+		return fmt.Errorf("offset is available only with assign: group or node")
+	}
+	switch pr.Type {
+	case ParameterRuleTypeRandomInt:
+		if pr.Max <= pr.Min {
+			return fmt.Errorf("max should be larger than min")
+		}
+	case ParameterRuleTypeHash:
+		if pr.Max != 0 && pr.Max <= pr.Min {
+			return fmt.Errorf("max should be larger than min")
+		}
+	case ParameterRuleTypeExpr:
+		if pr.Expr == "" {
+			return fmt.Errorf("expr is required for type expr")
+		}
+		if pr.IsAttachMode() {
+			return fmt.Errorf("type expr is not available in attach mode")
+		}
+		if _, err := pr.ExprTemplate(); err != nil {
+			return err
+		}
+	case ParameterRuleTypeMAC:
+		if pr.Prefix != "" {
+			if _, _, err := ParseMACPrefix(pr.Prefix); err != nil {
+				return err
+			}
+		}
+		if _, err := FormatMAC(0, pr.Format); err != nil {
+			return err
+		}
+		if pr.Min < 0 || pr.Max < 0 {
+			return fmt.Errorf("min and max should not be negative")
+		}
+		if (pr.Assign == ParameterRuleAssignGroup || pr.Assign == ParameterRuleAssignNode) && pr.Offset == "" {
+			// MAC addresses restarting in each group or node would be duplicated
+			return fmt.Errorf("type mac with assign: %s requires offset to avoid duplicated addresses", pr.Assign)
+		}
+	}
+	if pr.Length < 0 {
+		return fmt.Errorf("length should not be negative")
+	}
+	if pr.Source != nil {
+		if err := pr.Source.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PodTemplate defines a sub-topology that is instantiated multiple times.
+// The sub-topology is given as a DOT subgraph (removed from the topology itself) or a separate DOT file.
+type PodTemplate struct {
+	Name string `yaml:"name" mapstructure:"name"`
+	// SubGraph is the name of the DOT subgraph used as the template
+	SubGraph string `yaml:"subgraph" mapstructure:"subgraph"`
+	// File is the DOT file used as the template (alternative to SubGraph)
+	File string `yaml:"file" mapstructure:"file"`
+	// Count is the number of instances, indexed from 1
+	Count int `yaml:"count" mapstructure:"count"`
+	// Prefix is the name prefix of instantiated nodes and subgraphs (default: "{{ .name }}{{ .index }}_")
+	Prefix string `yaml:"prefix" mapstructure:"prefix"`
+	// Group is the name of the group of each instance (default: "{{ .name }}{{ .index }}")
+	Group string `yaml:"group" mapstructure:"group"`
+	// GroupClasses are class labels given to the instance groups
+	GroupClasses []string `yaml:"groupclass,flow" mapstructure:"groupclass,flow"`
+	// IndexParam is the parameter name of the instance index,
+	// given to the instance groups and nodes (default: "<name>_index")
+	IndexParam string `yaml:"index_param" mapstructure:"index_param"`
+	// Attach maps attachment point names (placeholder nodes in the template) to shared node names.
+	// Shared node names can include {{ .index }} to connect instances to different nodes.
+	Attach map[string][]string `yaml:"attach" mapstructure:"attach"`
+}
+
+const DefaultPodTemplatePrefix string = "{{ .name }}{{ .index }}_"
+const DefaultPodTemplateGroup string = "{{ .name }}{{ .index }}"
+const PodTemplateIndexParamSuffix string = "_index"
+
+// GetIndexParam returns the parameter name of the instance index
+func (pt *PodTemplate) GetIndexParam() string {
+	if pt.IndexParam == "" {
+		return pt.Name + PodTemplateIndexParamSuffix
+	}
+	return pt.IndexParam
+}
+
+// ClassifyRule assigns a class label to the objects that satisfy all the given predicates.
+// Rules are applied in the given order, so a rule can refer to the classes assigned by former rules.
+type ClassifyRule struct {
+	// Class is the class label to assign
+	Class string `yaml:"class" mapstructure:"class"`
+	// Target is the object type to classify: node (default), interface, or connection
+	Target string `yaml:"target" mapstructure:"target"`
+
+	// Name is a regular expression of node names (for node) or interface names (for interface).
+	// Interfaces are classified before automatic naming, so interface names must be given as DOT port names.
+	Name string `yaml:"name" mapstructure:"name"`
+	// Group is the name or the class of groups that the node belongs to (for node)
+	Group string `yaml:"group" mapstructure:"group"`
+	// MinDegree and MaxDegree limit the number of links of the node (for node)
+	MinDegree int  `yaml:"min_degree" mapstructure:"min_degree"`
+	MaxDegree *int `yaml:"max_degree" mapstructure:"max_degree"`
+	// NeighborClasses requires at least one adjacent node of any of the classes (for node)
+	NeighborClasses []string `yaml:"neighbor_classes,flow" mapstructure:"neighbor_classes,flow"`
+	// Root is the node class of root nodes, and Distance is the hop count from the nearest root node (for node)
+	// e.g., root: spine, distance: 1 -> nodes adjacent to spine nodes
+	Root     string `yaml:"root" mapstructure:"root"`
+	Distance int    `yaml:"distance" mapstructure:"distance"`
+
+	// NodeClasses requires the node of the interface (or one end node of the connection)
+	// to have any of the classes (for interface and connection)
+	NodeClasses []string `yaml:"node_classes,flow" mapstructure:"node_classes,flow"`
+	// OppositeNodeClasses requires the opposite node of the interface (or the other end node of the connection)
+	// to have any of the classes (for interface and connection)
+	OppositeNodeClasses []string `yaml:"opposite_node_classes,flow" mapstructure:"opposite_node_classes,flow"`
+}
+
+// GetTarget returns the target object type, defaulting to node
+func (cr *ClassifyRule) GetTarget() string {
+	if cr.Target == "" {
+		return ClassTypeNode
+	}
+	return cr.Target
+}
+
+func (cr *ClassifyRule) validate() error {
+	if cr.Class == "" {
+		return fmt.Errorf("class is required")
+	}
+	switch cr.GetTarget() {
+	case ClassTypeNode:
+		if len(cr.NodeClasses) > 0 || len(cr.OppositeNodeClasses) > 0 {
+			return fmt.Errorf("node_classes and opposite_node_classes are not available for node")
+		}
+	case ClassTypeInterface, ClassTypeConnection:
+		if cr.Group != "" || cr.MinDegree != 0 || cr.MaxDegree != nil ||
+			len(cr.NeighborClasses) > 0 || cr.Root != "" {
+			return fmt.Errorf("node predicates are not available for %s", cr.GetTarget())
+		}
+		if cr.GetTarget() == ClassTypeConnection && cr.Name != "" {
+			return fmt.Errorf("name is not available for connection")
+		}
+	default:
+		return fmt.Errorf("invalid target %s", cr.Target)
+	}
+	if cr.Name != "" {
+		if _, err := regexp.Compile(cr.Name); err != nil {
+			return fmt.Errorf("invalid name pattern %s: %w", cr.Name, err)
+		}
+	}
+	return nil
+}
+
 // interfaces and abstracted structs for object classes
 
 type ObjectClass interface{}
@@ -624,8 +1133,9 @@ func (nc *NetworkClass) GetGivenValues() map[string]string {
 
 type NodeClass struct {
 	// A virtual node have parameters, but no object nor configuration. It is considered only on parameter assignment.
-	Name    string `yaml:"name" mapstructure:"name"`
-	Virtual bool   `yaml:"virtual" mapstructure:"virtual"`
+	Name              string            `yaml:"name" mapstructure:"name"`
+	Extends           []string          `yaml:"extends,flow" mapstructure:"extends,flow"` // parent classes to inherit
+	Virtual           bool              `yaml:"virtual" mapstructure:"virtual"`
 	IPPolicy          []string          `yaml:"policy,flow" mapstructure:"policy,flow"`
 	Parameters        []string          `yaml:"params,flow" mapstructure:"params,flow"` // Parameter policies
 	Values            map[string]string `yaml:"values" mapstructure:"values"`
@@ -644,8 +1154,9 @@ func (nc *NodeClass) GetGivenValues() map[string]string {
 }
 
 type InterfaceClass struct {
-	Name    string `yaml:"name" mapstructure:"name"`
-	Virtual bool   `yaml:"virtual" mapstructure:"virtual"`
+	Name            string            `yaml:"name" mapstructure:"name"`
+	Extends         []string          `yaml:"extends,flow" mapstructure:"extends,flow"` // parent classes to inherit
+	Virtual         bool              `yaml:"virtual" mapstructure:"virtual"`
 	IPPolicy        []string          `yaml:"policy,flow" mapstructure:"policy,flow"`
 	Layers          []string          `yaml:"layers,flow" mapstructure:"layers,flow"` // Interface connection is limited to specified layers
 	Parameters      []string          `yaml:"params,flow" mapstructure:"params,flow"` // Parameter policies
@@ -665,6 +1176,7 @@ func (ic *InterfaceClass) GetGivenValues() map[string]string {
 
 type ConnectionClass struct {
 	Name            string            `yaml:"name" mapstructure:"name"`
+	Extends         []string          `yaml:"extends,flow" mapstructure:"extends,flow"` // parent classes to inherit
 	Virtual         bool              `yaml:"virtual" mapstructure:"virtual"`
 	IPPolicy        []string          `yaml:"policy,flow" mapstructure:"policy,flow"`
 	Layers          []string          `yaml:"layers,flow" mapstructure:"layers,flow"` // Connection is limited to specified layers
@@ -685,6 +1197,7 @@ func (cc *ConnectionClass) GetGivenValues() map[string]string {
 
 type GroupClass struct {
 	Name            string            `yaml:"name" mapstructure:"name"`
+	Extends         []string          `yaml:"extends,flow" mapstructure:"extends,flow"` // parent classes to inherit
 	Virtual         bool              `yaml:"virtual" mapstructure:"virtual"`
 	Parameters      []string          `yaml:"params,flow" mapstructure:"params,flow"` // Parameter policies
 	Values          map[string]string `yaml:"values" mapstructure:"values"`
@@ -699,6 +1212,7 @@ func (gc *GroupClass) GetGivenValues() map[string]string {
 
 type SegmentClass struct {
 	Name            string            `yaml:"name" mapstructure:"name"`
+	Extends         []string          `yaml:"extends,flow" mapstructure:"extends,flow"` // parent classes to inherit
 	Layer           string            `yaml:"layer" mapstructure:"layer"`
 	Parameters      []string          `yaml:"params,flow" mapstructure:"params,flow"` // Parameter policies
 	ConfigTemplates []*ConfigTemplate `yaml:"config,flow" mapstructure:"config,flow"`
@@ -756,6 +1270,15 @@ func (mc *MemberClass) GetSpecifiedClasses() (string, []string, error) {
 	}
 }
 
+// TemplateLibrary defines shared templates available in all config templates.
+// Templates defined with {{ define "name" }} are used as {{ template "name" . }}.
+type TemplateLibrary struct {
+	// Template is an inline template library
+	Template []string `yaml:"template" mapstructure:"template"`
+	// Files are glob patterns of template library files
+	Files []string `yaml:"files,flow" mapstructure:"files,flow"`
+}
+
 // BlocksConfig defines config blocks to be merged before/after the template
 type BlocksConfig struct {
 	Before []string `yaml:"before" mapstructure:"before"`
@@ -811,8 +1334,7 @@ type ConfigTemplate struct {
 	// If any of the specified parameters are missing, the entire block is skipped
 	RequiredParams []string `yaml:"required_params,flow" mapstructure:"required_params,flow"`
 
-	// This option is valid only on InterfaceClass or ConnectionClass
-	// If specified, add config only for included output (e.g., tinet only, clab only, etc)
+	// If specified, add config only when one of the platforms is selected (e.g., tinet only, clab only, etc)
 	Platform []string `yaml:"platform,flow" mapstructure:"platform,flow"`
 	// Style is used to iterpret the given config format. Style can be different on one file. As-is in default.
 	//Style string `yaml:"style" mapstructure:"style"`
@@ -824,9 +1346,11 @@ type ConfigTemplate struct {
 	Template []string `yaml:"template" mapstructure:"template"`
 	// Load config template from external file
 	SourceFile string `yaml:"sourcefile" mapstructure:"sourcefile"`
+	// Structured gives the object structure to the template as .Obj in addition to the parameters
+	// (e.g., {{ range .Obj.Interfaces }}, {{ .Obj.Neighbors "ipv4" }}, {{ .Obj.Groups }}, {{ .Obj.Values "rule" }})
+	Structured bool `yaml:"structured" mapstructure:"structured"`
 
 	ParsedTemplate *template.Template
-	platformSet    mapset.Set[string]
 	className      string
 	classType      string
 }
@@ -849,6 +1373,20 @@ func (ct *ConfigTemplate) String() string {
 	}
 }
 
+// PlatformCheck returns true if the config template is available on the selected platform.
+// Any config template is available if no platform is selected.
+func (ct *ConfigTemplate) PlatformCheck(platform string) bool {
+	if platform == "" || len(ct.Platform) == 0 {
+		return true
+	}
+	for _, p := range ct.Platform {
+		if p == platform {
+			return true
+		}
+	}
+	return false
+}
+
 func (ct *ConfigTemplate) GetClassInfo() (string, string) {
 	return ct.classType, ct.className
 }
@@ -975,6 +1513,15 @@ func convertLineFeed(str, lcode string) string {
 	).Replace(str)
 }
 
+// SetPlatform selects the target platform, overriding global.platform in the config file.
+func (cfg *Config) SetPlatform(platform string) error {
+	if err := checkPlatform(platform); err != nil {
+		return err
+	}
+	cfg.GlobalSettings.Platform = platform
+	return nil
+}
+
 func GetRelativeFilePath(path string, cfg *Config) string {
 	pathspec := cfg.GlobalSettings.PathSpecification
 	if pathspec == "local" {
@@ -991,15 +1538,29 @@ func LoadConfig(path string) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	// reject unknown keys to detect typos in config files
+	err = checkUnknownFields(bytes, reflect.TypeOf(Config{}))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
 	err = yaml.Unmarshal(bytes, &cfg)
 	if err != nil {
 		return nil, err
 	}
+	if err := cfg.GlobalSettings.validate(); err != nil {
+		return nil, fmt.Errorf("invalid global settings: %w", err)
+	}
 
 	// add empty filedef for embedded conifg
 	cfg.FileDefinitions = append(cfg.FileDefinitions, &FileDefinition{Name: "", Format: "shell"})
 
 	cfg.localDir = filepath.Dir(path)
+	if cfg.ObjectValuesFile != "" {
+		cfg.ObjectValues, err = LoadObjectValues(GetRelativeFilePath(cfg.ObjectValuesFile, &cfg))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load object values: %w", err)
+		}
+	}
 	cfg.fileDefinitionMap = map[string]*FileDefinition{}
 	for _, filedef := range cfg.FileDefinitions {
 		cfg.fileDefinitionMap[filedef.Name] = filedef
@@ -1016,6 +1577,9 @@ func LoadConfig(path string) (*Config, error) {
 	for _, layer := range cfg.Layers {
 		cfg.layerMap[layer.Name] = layer
 		for _, policy := range layer.Policies {
+			if err := policy.validate(); err != nil {
+				return nil, err
+			}
 			policy.layer = layer
 			cfg.policyMap[policy.Name] = policy
 			switch policy.Type {
@@ -1034,8 +1598,36 @@ func LoadConfig(path string) (*Config, error) {
 		if msg := CheckReservedParamName(prule.Name); msg != "" {
 			return nil, fmt.Errorf("in 'param_rule' section (name: %s): %s", prule.Name, msg)
 		}
+		if err := prule.validate(); err != nil {
+			return nil, fmt.Errorf("in 'param_rule' section (name: %s): %w", prule.Name, err)
+		}
 		cfg.parameterRuleMap[prule.Name] = prule
 	}
+	for _, pt := range cfg.PodTemplates {
+		if pt.Name == "" {
+			return nil, fmt.Errorf("in 'podtemplate' section: name is required")
+		}
+		if (pt.SubGraph == "") == (pt.File == "") {
+			return nil, fmt.Errorf("in 'podtemplate' section (name: %s): specify either subgraph or file", pt.Name)
+		}
+		if pt.Count < 1 {
+			return nil, fmt.Errorf("in 'podtemplate' section (name: %s): count must be positive", pt.Name)
+		}
+		if msg := CheckReservedParamName(pt.GetIndexParam()); msg != "" {
+			return nil, fmt.Errorf("in 'podtemplate' section (name: %s): %s", pt.Name, msg)
+		}
+	}
+
+	for _, rule := range cfg.ClassifyRules {
+		if err := rule.validate(); err != nil {
+			return nil, fmt.Errorf("in 'classify' section (class: %s): %w", rule.Class, err)
+		}
+	}
+
+	// flatten classes with extends before building class maps
+	if err := cfg.resolveInheritance(); err != nil {
+		return nil, err
+	}
 
 	cfg.nodeClassMap = map[string]*NodeClass{}
 	for _, node := range cfg.NodeClasses {
@@ -1071,40 +1663,72 @@ func LoadConfig(path string) (*Config, error) {
 	return &cfg, err
 }
 
-func loadTemplate(tpl []string, path string) (*template.Template, error) {
+// loadTemplateLibrary parses the template library shared by all config templates.
+func loadTemplateLibrary(cfg *Config) (*template.Template, error) {
+	lib, err := NewTemplate().Parse(strings.Join(cfg.TemplateLibrary.Template, "\n"))
+	if err != nil {
+		return nil, err
+	}
+	for _, pattern := range cfg.TemplateLibrary.Files {
+		paths, err := filepath.Glob(GetRelativeFilePath(pattern, cfg))
+		if err != nil {
+			return nil, err
+		}
+		if len(paths) == 0 {
+			return nil, fmt.Errorf("no template library file matches %s", pattern)
+		}
+		for _, path := range paths {
+			bytes, err := os.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			// parse in a separated template so that the text out of define blocks is ignored
+			_, err = lib.New(path).Parse(convertLineFeed(string(bytes), "\n"))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return lib, nil
+}
+
+// loadTemplate parses a config template associated with the template library.
+func loadTemplate(lib *template.Template, tpl []string, path string) (*template.Template, error) {
+	var buf string
 	if len(tpl) == 0 && path == "" {
-		return template.New("").Parse("")
+		buf = ""
 		//return nil, fmt.Errorf("empty config template")
 	} else if len(tpl) == 0 {
 		bytes, err := os.ReadFile(path)
 		if err != nil {
 			return nil, err
 		}
-		buf := convertLineFeed(string(bytes), "\n")
-		return template.New("").Parse(buf)
+		buf = convertLineFeed(string(bytes), "\n")
 	} else if path == "" {
-		buf := strings.Join(tpl, "\n")
-		return template.New("").Parse(buf)
+		buf = strings.Join(tpl, "\n")
 	} else {
 		bytes, err := os.ReadFile(path)
 		if err != nil {
 			return nil, err
 		}
-		buf := strings.Join(tpl, "\n") + "\n" + convertLineFeed(string(bytes), "\n")
-		return template.New("").Parse(buf)
+		buf = strings.Join(tpl, "\n") + "\n" + convertLineFeed(string(bytes), "\n")
 	}
+
+	if lib == nil {
+		return NewTemplate().Parse(buf)
+	}
+	t, err := lib.Clone()
+	if err != nil {
+		return nil, err
+	}
+	return t.Parse(buf)
 }
 
 func initConfigTemplate(cfg *Config, ct *ConfigTemplate) error {
-	var outputs []string
-	ct.platformSet = mapset.NewSet[string]()
-	if len(ct.Platform) == 0 {
-		outputs = AllOutput()
-	} else {
-		outputs = ct.Platform
-	}
-	for _, output := range outputs {
-		ct.platformSet.Add(output)
+	for _, platform := range ct.Platform {
+		if err := checkPlatform(platform); err != nil {
+			return fmt.Errorf("invalid config template %s: %w", ct, err)
+		}
 	}
 
 	// check if the config template is sort-style
@@ -1121,7 +1745,7 @@ func initConfigTemplate(cfg *Config, ct *ConfigTemplate) error {
 	if ct.SourceFile != "" {
 		path = GetRelativeFilePath(ct.SourceFile, cfg)
 	}
-	tpl, err := loadTemplate(ct.Template, path)
+	tpl, err := loadTemplate(cfg.templateLibrary, ct.Template, path)
 	if err != nil {
 		return fmt.Errorf("failed to load template %+v: %w", ct, err)
 	}
@@ -1131,6 +1755,12 @@ func initConfigTemplate(cfg *Config, ct *ConfigTemplate) error {
 }
 
 func LoadTemplates(cfg *Config) (*Config, error) {
+	lib, err := loadTemplateLibrary(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load template library: %w", err)
+	}
+	cfg.templateLibrary = lib
+
 	// className is set only for LabelOwners, for checking config template conditions of classnames
 	for _, networkClass := range cfg.NetworkClasses {
 		for _, ct := range networkClass.ConfigTemplates {
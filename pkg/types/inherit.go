@@ -0,0 +1,295 @@
+package types
+
+import (
+	"fmt"
+)
+
+// inheritableClass is an object class that can extend other classes of the same kind.
+type inheritableClass[T any] interface {
+	className() string
+	parentNames() []string
+	inherit(parents []T)
+}
+
+// resolveClassInheritance flattens classes with extends in place.
+// Parent classes are merged in the given order, and the child class is merged at last,
+// so that later definitions override earlier ones.
+func resolveClassInheritance[T inheritableClass[T]](kind string, classes []T) error {
+	classMap := map[string]T{}
+	for _, cls := range classes {
+		classMap[cls.className()] = cls
+	}
+
+	resolved := map[string]bool{}
+	visiting := map[string]bool{}
+	var resolve func(cls T) error
+	resolve = func(cls T) error {
+		name := cls.className()
+		if resolved[name] {
+			return nil
+		}
+		if visiting[name] {
+			return fmt.Errorf("circular inheritance of %s %s", kind, name)
+		}
+		visiting[name] = true
+
+		parents := make([]T, 0, len(cls.parentNames()))
+		for _, pname := range cls.parentNames() {
+			parent, ok := classMap[pname]
+			if !ok {
+				return fmt.Errorf("%s %s extends undefined class %s", kind, name, pname)
+			}
+			if err := resolve(parent); err != nil {
+				return err
+			}
+			parents = append(parents, parent)
+		}
+		if len(parents) > 0 {
+			cls.inherit(parents)
+		}
+
+		visiting[name] = false
+		resolved[name] = true
+		return nil
+	}
+
+	for _, cls := range classes {
+		if err := resolve(cls); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeStringLists returns the union of given lists, keeping the order of first appearance.
+func mergeStringLists(lists ...[]string) []string {
+	var ret []string
+	seen := map[string]bool{}
+	for _, list := range lists {
+		for _, s := range list {
+			if !seen[s] {
+				seen[s] = true
+				ret = append(ret, s)
+			}
+		}
+	}
+	return ret
+}
+
+// mergeValueMaps merges given maps, later maps override earlier ones.
+func mergeValueMaps(maps ...map[string]string) map[string]string {
+	var ret map[string]string
+	for _, m := range maps {
+		for k, v := range m {
+			if ret == nil {
+				ret = map[string]string{}
+			}
+			ret[k] = v
+		}
+	}
+	return ret
+}
+
+// mergeConfigTemplates merges given config template lists.
+// A named config template overrides the earlier one with the same name at its position.
+// Config templates are copied so that each class has its own config template objects.
+func mergeConfigTemplates(lists ...[]*ConfigTemplate) []*ConfigTemplate {
+	var ret []*ConfigTemplate
+	named := map[string]int{}
+	for _, list := range lists {
+		for _, ct := range list {
+			copied := *ct
+			if ct.Name != "" {
+				if idx, ok := named[ct.Name]; ok {
+					ret[idx] = &copied
+					continue
+				}
+				named[ct.Name] = len(ret)
+			}
+			ret = append(ret, &copied)
+		}
+	}
+	return ret
+}
+
+func copyMemberClasses(lists ...[]*MemberClass) []*MemberClass {
+	var ret []*MemberClass
+	for _, list := range lists {
+		for _, mc := range list {
+			copied := *mc
+			copied.ConfigTemplates = mergeConfigTemplates(mc.ConfigTemplates)
+			ret = append(ret, &copied)
+		}
+	}
+	return ret
+}
+
+func copyNeighborClasses(lists ...[]*NeighborClass) []*NeighborClass {
+	var ret []*NeighborClass
+	for _, list := range lists {
+		for _, nc := range list {
+			copied := *nc
+			copied.ConfigTemplates = mergeConfigTemplates(nc.ConfigTemplates)
+			ret = append(ret, &copied)
+		}
+	}
+	return ret
+}
+
+// lastNonEmpty returns the last non-empty string.
+func lastNonEmpty(vals ...string) string {
+	ret := ""
+	for _, v := range vals {
+		if v != "" {
+			ret = v
+		}
+	}
+	return ret
+}
+
+func (nc *NodeClass) className() string     { return nc.Name }
+func (nc *NodeClass) parentNames() []string { return nc.Extends }
+
+func (nc *NodeClass) inherit(parents []*NodeClass) {
+	chain := append(append([]*NodeClass{}, parents...), nc)
+	var policies, params, ifpolicies, prefixes, mgmts []string
+	var values []map[string]string
+	var cts [][]*ConfigTemplate
+	var mcs [][]*MemberClass
+	for _, c := range chain {
+		nc.Virtual = nc.Virtual || c.Virtual
+		policies = append(policies, c.IPPolicy...)
+		params = append(params, c.Parameters...)
+		ifpolicies = append(ifpolicies, c.InterfaceIPPolicy...)
+		values = append(values, c.Values)
+		cts = append(cts, c.ConfigTemplates)
+		mcs = append(mcs, c.MemberClasses)
+		prefixes = append(prefixes, c.Prefix)
+		mgmts = append(mgmts, c.MgmtInterface)
+	}
+	nc.IPPolicy = mergeStringLists(policies)
+	nc.Parameters = mergeStringLists(params)
+	nc.InterfaceIPPolicy = mergeStringLists(ifpolicies)
+	nc.Values = mergeValueMaps(values...)
+	nc.ConfigTemplates = mergeConfigTemplates(cts...)
+	nc.MemberClasses = copyMemberClasses(mcs...)
+	nc.Prefix = lastNonEmpty(prefixes...)
+	nc.MgmtInterface = lastNonEmpty(mgmts...)
+}
+
+func (ic *InterfaceClass) className() string     { return ic.Name }
+func (ic *InterfaceClass) parentNames() []string { return ic.Extends }
+
+func (ic *InterfaceClass) inherit(parents []*InterfaceClass) {
+	chain := append(append([]*InterfaceClass{}, parents...), ic)
+	var policies, layers, params, prefixes []string
+	var values []map[string]string
+	var cts [][]*ConfigTemplate
+	var ncs [][]*NeighborClass
+	var mcs [][]*MemberClass
+	for _, c := range chain {
+		ic.Virtual = ic.Virtual || c.Virtual
+		policies = append(policies, c.IPPolicy...)
+		layers = append(layers, c.Layers...)
+		params = append(params, c.Parameters...)
+		values = append(values, c.Values)
+		cts = append(cts, c.ConfigTemplates)
+		ncs = append(ncs, c.NeighborClasses)
+		mcs = append(mcs, c.MemberClasses)
+		prefixes = append(prefixes, c.Prefix)
+	}
+	ic.IPPolicy = mergeStringLists(policies)
+	ic.Layers = mergeStringLists(layers)
+	ic.Parameters = mergeStringLists(params)
+	ic.Values = mergeValueMaps(values...)
+	ic.ConfigTemplates = mergeConfigTemplates(cts...)
+	ic.NeighborClasses = copyNeighborClasses(ncs...)
+	ic.MemberClasses = copyMemberClasses(mcs...)
+	ic.Prefix = lastNonEmpty(prefixes...)
+}
+
+func (cc *ConnectionClass) className() string     { return cc.Name }
+func (cc *ConnectionClass) parentNames() []string { return cc.Extends }
+
+func (cc *ConnectionClass) inherit(parents []*ConnectionClass) {
+	chain := append(append([]*ConnectionClass{}, parents...), cc)
+	var policies, layers, params, prefixes []string
+	var values []map[string]string
+	var cts [][]*ConfigTemplate
+	var mcs [][]*MemberClass
+	for _, c := range chain {
+		cc.Virtual = cc.Virtual || c.Virtual
+		policies = append(policies, c.IPPolicy...)
+		layers = append(layers, c.Layers...)
+		params = append(params, c.Parameters...)
+		values = append(values, c.Values)
+		cts = append(cts, c.ConfigTemplates)
+		mcs = append(mcs, c.MemberClasses)
+		prefixes = append(prefixes, c.Prefix)
+	}
+	cc.IPPolicy = mergeStringLists(policies)
+	cc.Layers = mergeStringLists(layers)
+	cc.Parameters = mergeStringLists(params)
+	cc.Values = mergeValueMaps(values...)
+	cc.ConfigTemplates = mergeConfigTemplates(cts...)
+	cc.MemberClasses = copyMemberClasses(mcs...)
+	cc.Prefix = lastNonEmpty(prefixes...)
+}
+
+func (gc *GroupClass) className() string     { return gc.Name }
+func (gc *GroupClass) parentNames() []string { return gc.Extends }
+
+func (gc *GroupClass) inherit(parents []*GroupClass) {
+	chain := append(append([]*GroupClass{}, parents...), gc)
+	var params []string
+	var values []map[string]string
+	var cts [][]*ConfigTemplate
+	for _, c := range chain {
+		gc.Virtual = gc.Virtual || c.Virtual
+		params = append(params, c.Parameters...)
+		values = append(values, c.Values)
+		cts = append(cts, c.ConfigTemplates)
+	}
+	gc.Parameters = mergeStringLists(params)
+	gc.Values = mergeValueMaps(values...)
+	gc.ConfigTemplates = mergeConfigTemplates(cts...)
+}
+
+func (sc *SegmentClass) className() string     { return sc.Name }
+func (sc *SegmentClass) parentNames() []string { return sc.Extends }
+
+func (sc *SegmentClass) inherit(parents []*SegmentClass) {
+	chain := append(append([]*SegmentClass{}, parents...), sc)
+	var layers, params, prefixes []string
+	var cts [][]*ConfigTemplate
+	for _, c := range chain {
+		layers = append(layers, c.Layer)
+		params = append(params, c.Parameters...)
+		cts = append(cts, c.ConfigTemplates)
+		prefixes = append(prefixes, c.Prefix)
+	}
+	sc.Layer = lastNonEmpty(layers...)
+	sc.Parameters = mergeStringLists(params)
+	sc.ConfigTemplates = mergeConfigTemplates(cts...)
+	sc.Prefix = lastNonEmpty(prefixes...)
+}
+
+// resolveInheritance flattens all object classes with extends.
+func (cfg *Config) resolveInheritance() error {
+	if err := resolveClassInheritance(ClassTypeNode+"class", cfg.NodeClasses); err != nil {
+		return err
+	}
+	if err := resolveClassInheritance(ClassTypeInterface+"class", cfg.InterfaceClasses); err != nil {
+		return err
+	}
+	if err := resolveClassInheritance(ClassTypeConnection+"class", cfg.ConnectionClasses); err != nil {
+		return err
+	}
+	if err := resolveClassInheritance(ClassTypeGroup+"class", cfg.GroupClasses); err != nil {
+		return err
+	}
+	if err := resolveClassInheritance(ClassTypeSegment+"class", cfg.SegmentClasses); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package types
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolveInheritance(t *testing.T) {
+	t.Run("NodeClass", func(t *testing.T) {
+		cfg := &Config{
+			NodeClasses: []*NodeClass{
+				{
+					Name:            "frr_bgp_router",
+					Extends:         []string{"frr_router", "bgp"},
+					Values:          map[string]string{"image": "frr:9"},
+					ConfigTemplates: []*ConfigTemplate{{Name: "bgp", Template: []string{"router bgp 65001"}}},
+				},
+				{
+					Name:       "frr_router",
+					Extends:    []string{"router"},
+					Parameters: []string{"router_id"},
+					Values:     map[string]string{"image": "frr:8", "kind": "linux"},
+					ConfigTemplates: []*ConfigTemplate{
+						{Name: "frr", Template: []string{"frr"}},
+						{Name: "bgp", Template: []string{"! no bgp"}},
+					},
+				},
+				{
+					Name:       "router",
+					Parameters: []string{"loopback", "router_id"},
+					Prefix:     "r",
+				},
+				{
+					Name:       "bgp",
+					Parameters: []string{"as"},
+					IPPolicy:   []string{"loopback"},
+				},
+			},
+		}
+		if err := cfg.resolveInheritance(); err != nil {
+			t.Fatal(err)
+		}
+		nc := cfg.NodeClasses[0]
+
+		if strings.Join(nc.Parameters, ",") != "loopback,router_id,as" {
+			t.Errorf("params mismatch: %v", nc.Parameters)
+		}
+		if nc.Values["image"] != "frr:9" || nc.Values["kind"] != "linux" {
+			t.Errorf("values mismatch: %v", nc.Values)
+		}
+		if nc.Prefix != "r" {
+			t.Errorf("prefix mismatch: %v", nc.Prefix)
+		}
+		if len(nc.IPPolicy) != 1 {
+			t.Errorf("policies mismatch: %v", nc.IPPolicy)
+		}
+		// the child config template "bgp" overrides the parent one at its position
+		if len(nc.ConfigTemplates) != 2 || nc.ConfigTemplates[0].Name != "frr" ||
+			nc.ConfigTemplates[1].Template[0] != "router bgp 65001" {
+			t.Errorf("config templates mismatch: %+v", nc.ConfigTemplates)
+		}
+		// config templates are not shared with parent classes
+		if nc.ConfigTemplates[0] == cfg.NodeClasses[1].ConfigTemplates[0] {
+			t.Errorf("config template is shared with parent class")
+		}
+		// parent class is also flattened
+		if strings.Join(cfg.NodeClasses[1].Parameters, ",") != "loopback,router_id" {
+			t.Errorf("parent params mismatch: %v", cfg.NodeClasses[1].Parameters)
+		}
+	})
+
+	t.Run("Undefined", func(t *testing.T) {
+		cfg := &Config{
+			InterfaceClasses: []*InterfaceClass{{Name: "a", Extends: []string{"b"}}},
+		}
+		err := cfg.resolveInheritance()
+		if err == nil || !strings.Contains(err.Error(), "undefined class b") {
+			t.Errorf("expected undefined class error, got %v", err)
+		}
+	})
+
+	t.Run("Circular", func(t *testing.T) {
+		cfg := &Config{
+			GroupClasses: []*GroupClass{
+				{Name: "a", Extends: []string{"b"}},
+				{Name: "b", Extends: []string{"a"}},
+			},
+		}
+		err := cfg.resolveInheritance()
+		if err == nil || !strings.Contains(err.Error(), "circular inheritance") {
+			t.Errorf("expected circular inheritance error, got %v", err)
+		}
+	})
+}
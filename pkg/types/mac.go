@@ -0,0 +1,141 @@
+package types
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// MAC address formats of param_rule type mac
+const (
+	// MACFormatColon is 02:42:ac:11:00:01 (default)
+	MACFormatColon = "colon"
+	// MACFormatDash is 02-42-ac-11-00-01
+	MACFormatDash = "dash"
+	// MACFormatCisco is 0242.ac11.0001
+	MACFormatCisco = "cisco"
+	// MACFormatEUI64 is the IPv6 link-local address derived by modified EUI-64 (fe80::42:acff:fe11:1)
+	MACFormatEUI64 = "eui64"
+)
+
+const macBits = 48
+
+// ParseMACPrefix parses a MAC address prefix with optional prefix length (e.g., "02:42:ac" or "02:42:ac:10/28").
+// The prefix length is the number of given bits in default.
+// The returned value is the prefix placed on the upper bits of the 48-bit address.
+func ParseMACPrefix(s string) (uint64, int, error) {
+	s = strings.TrimSpace(s)
+	bits := 0
+	hasLength := false
+	if idx := strings.Index(s, "/"); idx >= 0 {
+		var err error
+		bits, err = strconv.Atoi(s[idx+1:])
+		if err != nil || bits < 0 {
+			return 0, 0, fmt.Errorf("invalid MAC prefix length in %s", s)
+		}
+		hasLength = true
+		s = s[:idx]
+	}
+	octets := strings.FieldsFunc(s, func(r rune) bool { return r == ':' || r == '-' })
+	if len(octets) == 0 || len(octets) > macBits/8 {
+		return 0, 0, fmt.Errorf("invalid MAC prefix %s", s)
+	}
+	var val uint64
+	for _, o := range octets {
+		b, err := strconv.ParseUint(o, 16, 8)
+		if err != nil || len(o) > 2 {
+			return 0, 0, fmt.Errorf("invalid MAC prefix %s", s)
+		}
+		val = val<<8 | b
+	}
+	val <<= uint(macBits - 8*len(octets))
+	if !hasLength {
+		bits = 8 * len(octets)
+	}
+	if bits > macBits {
+		return 0, 0, fmt.Errorf("invalid MAC prefix length %d", bits)
+	}
+	if bits < macBits && val&(1<<uint(macBits-bits)-1) != 0 {
+		return 0, 0, fmt.Errorf("MAC prefix %s has host bits out of the prefix length %d", s, bits)
+	}
+	if val>>40&0x01 != 0 {
+		return 0, 0, fmt.Errorf("MAC prefix %s is multicast", s)
+	}
+	return val, bits, nil
+}
+
+// DefaultMACPrefix returns a locally administered unicast prefix (/24) derived from the key
+// (e.g., network name), so that labs sharing a host get different MAC addresses in default.
+func DefaultMACPrefix(key string) (uint64, int) {
+	sum := sha256.Sum256([]byte(key))
+	return (uint64(0x02)<<16 | uint64(sum[0])<<8 | uint64(sum[1])) << 24, 24
+}
+
+// FormatMAC formats a 48-bit MAC address.
+func FormatMAC(val uint64, format string) (string, error) {
+	buf := make([]byte, 6)
+	for i := range buf {
+		buf[i] = byte(val >> uint(8*(5-i)))
+	}
+	switch format {
+	case "", MACFormatColon:
+		return net.HardwareAddr(buf).String(), nil
+	case MACFormatDash:
+		return strings.ReplaceAll(net.HardwareAddr(buf).String(), ":", "-"), nil
+	case MACFormatCisco:
+		return fmt.Sprintf("%02x%02x.%02x%02x.%02x%02x", buf[0], buf[1], buf[2], buf[3], buf[4], buf[5]), nil
+	case MACFormatEUI64:
+		return macLinkLocal(buf), nil
+	default:
+		return "", fmt.Errorf("unknown MAC format %s", format)
+	}
+}
+
+// macLinkLocal returns the IPv6 link-local address of the MAC address by modified EUI-64.
+func macLinkLocal(mac net.HardwareAddr) string {
+	addr := [16]byte{0xfe, 0x80}
+	copy(addr[8:11], mac[0:3])
+	addr[8] ^= 0x02 // universal/local bit
+	addr[11] = 0xff
+	addr[12] = 0xfe
+	copy(addr[13:16], mac[3:6])
+	return netip.AddrFrom16(addr).String()
+}
+
+// parseMAC parses a MAC address in colon, dash or Cisco dotted format.
+func parseMAC(s string) (net.HardwareAddr, error) {
+	mac, err := net.ParseMAC(strings.TrimSpace(s))
+	if err != nil {
+		return nil, err
+	}
+	if len(mac) != 6 {
+		return nil, fmt.Errorf("%s is not a 48-bit MAC address", s)
+	}
+	return mac, nil
+}
+
+// macFormat converts the MAC address into the format (e.g., macFormat "02:42:ac:11:00:01" "cisco" -> "0242.ac11.0001").
+func macFormat(s string, format string) (string, error) {
+	mac, err := parseMAC(s)
+	if err != nil {
+		return "", err
+	}
+	var val uint64
+	for _, b := range mac {
+		val = val<<8 | uint64(b)
+	}
+	return FormatMAC(val, format)
+}
+
+// macToLinkLocal returns the IPv6 link-local address of the MAC address by modified EUI-64
+// (e.g., macToLinkLocal "02:42:ac:11:00:01" -> "fe80::42:acff:fe11:1").
+func macToLinkLocal(s string) (string, error) {
+	mac, err := parseMAC(s)
+	if err != nil {
+		return "", err
+	}
+	return macLinkLocal(mac), nil
+}
@@ -0,0 +1,74 @@
+package types
+
+import (
+	"testing"
+)
+
+func TestParseMACPrefix(t *testing.T) {
+	tests := []struct {
+		prefix string
+		val    uint64
+		bits   int
+	}{
+		{"02:42:ac", 0x0242ac000000, 24},
+		{"02-42-ac-10", 0x0242ac100000, 32},
+		{"02:42:ac:10/28", 0x0242ac100000, 28},
+		{"02:42:a0/20", 0x0242a0000000, 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			val, bits, err := ParseMACPrefix(tt.prefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if val != tt.val || bits != tt.bits {
+				t.Errorf("expected %x/%d, got %x/%d", tt.val, tt.bits, val, bits)
+			}
+		})
+	}
+
+	errTests := []string{
+		"",
+		"02:42:xx",
+		"02:42:ac:11:00:01:02",
+		"02:42:ac:10/24",
+		"01:00:5e",
+		"02:42:ac/49",
+		"02:42:ac/-4",
+		"02:42:ac/20",
+	}
+	for _, prefix := range errTests {
+		t.Run(prefix, func(t *testing.T) {
+			if _, _, err := ParseMACPrefix(prefix); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func TestFormatMAC(t *testing.T) {
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{"", "02:42:ac:11:00:01"},
+		{MACFormatColon, "02:42:ac:11:00:01"},
+		{MACFormatDash, "02-42-ac-11-00-01"},
+		{MACFormatCisco, "0242.ac11.0001"},
+		{MACFormatEUI64, "fe80::42:acff:fe11:1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			mac, err := FormatMAC(0x0242ac110001, tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mac != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, mac)
+			}
+		})
+	}
+	if _, err := FormatMAC(0, "dotted"); err == nil {
+		t.Errorf("expected error for unknown format")
+	}
+}
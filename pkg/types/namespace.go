@@ -26,6 +26,9 @@ const ChildMembersConfigHeader string = "members" + NumberSeparator
 // Value reference prefix for template parameters
 const ValueReferencePrefix string = "values" + NumberSeparator
 
+// DOT attribute prefix for template parameters (enabled with global.dot_attributes)
+const DotAttrPrefix string = "dot_attr" + NumberSeparator
+
 // Reserved parameter name for object name
 const ReservedParamName string = "name"
 
@@ -128,6 +131,7 @@ func ReservedPrefixes() []string {
 		ChildNeighborsConfigHeader,
 		ChildMembersConfigHeader,
 		ValueReferencePrefix,
+		DotAttrPrefix,
 	}
 }
 
@@ -199,6 +203,8 @@ func describeReservedPrefix(prefix string) string {
 		return "for child members config references"
 	case ValueReferencePrefix:
 		return "for Value class references"
+	case DotAttrPrefix:
+		return "for DOT attribute references"
 	default:
 		return "reserved for internal use"
 	}
@@ -18,6 +18,7 @@ func TestReservedPrefixes(t *testing.T) {
 		NumberPrefixMember,
 		SelfConfigHeader,
 		ValueReferencePrefix,
+		DotAttrPrefix,
 	}
 
 	for _, expected := range expectedPrefixes {
@@ -120,6 +120,8 @@ type NameSpacer interface {
 	GetParams() map[string]string
 	GetRelativeParams() map[string]string
 	GetParamValue(string) (string, error)
+	SetParamSource(k, src string)
+	GetParamSource(k string) string
 
 	GetConfigTemplates(cfg *Config) []*ConfigTemplate
 	GetPossibleConfigTemplates(cfg *Config) []*ConfigTemplate
@@ -132,6 +134,7 @@ type NameSpace struct {
 	paramFlags     mapset.Set[string]
 	params         map[string]string
 	relativeParams map[string]string
+	paramSources   map[string]string // sources of given params, for audit
 }
 
 func newNameSpace() *NameSpace {
@@ -210,6 +213,19 @@ func (ns *NameSpace) GetParamValue(key string) (string, error) {
 	}
 }
 
+// SetParamSource records the source of a given parameter (e.g., label, class router)
+func (ns *NameSpace) SetParamSource(k, src string) {
+	if ns.paramSources == nil {
+		ns.paramSources = map[string]string{}
+	}
+	ns.paramSources[k] = src
+}
+
+// GetParamSource returns the source of a given parameter, or empty string if unknown
+func (ns *NameSpace) GetParamSource(k string) string {
+	return ns.paramSources[k]
+}
+
 // LabelOwner includes Node, Interface, Connection, Group
 type LabelOwner interface {
 	ClassLabels() []string
@@ -244,6 +260,7 @@ type ParsedLabels struct {
 	metaValueLabels map[string]string
 	Classes         []ObjectClass
 	virtual         bool // virtual object flag
+	defaultClass    bool // default class is given because of no class labels
 }
 
 func newParsedLabels() *ParsedLabels {
@@ -280,6 +297,24 @@ func (l *ParsedLabels) AddClassLabels(labels ...string) {
 	l.classLabels = append(l.classLabels, labels...)
 }
 
+// addClassLabel adds a class label if not yet given.
+// The default class given automatically is replaced with the new class label.
+func (l *ParsedLabels) addClassLabel(name string) {
+	if l.defaultClass {
+		labels := []string{}
+		for _, cls := range l.classLabels {
+			if cls != ClassDefault {
+				labels = append(labels, cls)
+			}
+		}
+		l.classLabels = labels
+		l.defaultClass = false
+	}
+	if !l.HasClass(name) {
+		l.classLabels = append(l.classLabels, name)
+	}
+}
+
 func (l *ParsedLabels) HasClass(name string) bool {
 	for _, cls := range l.classLabels {
 		if cls == name {
@@ -825,7 +860,7 @@ func (nm *NetworkModel) FilesToGenerate(cfg *Config) []string {
 	// Collect all file names from NetworkClass ConfigTemplates
 	for _, nc := range cfg.NetworkClasses {
 		for _, ct := range nc.ConfigTemplates {
-			if ct.File != "" {
+			if ct.File != "" && ct.PlatformCheck(cfg.GlobalSettings.Platform) {
 				fileSet[ct.File] = true
 			}
 		}
@@ -907,6 +942,20 @@ func (n *Node) SetLabels(cfg *Config, labels []string, moduleLabels []string) er
 	return nil
 }
 
+// AddClass adds a class to the node after SetLabels (e.g., by classify rules).
+func (n *Node) AddClass(cfg *Config, name string) error {
+	if _, ok := cfg.NodeClassByName(name); !ok {
+		return fmt.Errorf("invalid nodeclass name %s", name)
+	}
+	n.ParsedLabels.addClassLabel(name)
+	n.ParsedLabels.Classes = []ObjectClass{}
+	for _, cls := range n.ClassLabels() {
+		nc, _ := cfg.NodeClassByName(cls)
+		n.ParsedLabels.Classes = append(n.ParsedLabels.Classes, nc)
+	}
+	return nil
+}
+
 func (n *Node) SetClasses(cfg *Config, nm *NetworkModel) error {
 	// Track conflicting values
 	seenValues := make(map[string]string)
@@ -1218,7 +1267,7 @@ func (n *Node) FilesToGenerate(cfg *Config) []string {
 		for _, nc := range cfg.NodeClasses {
 			if nc.Name == classLabel {
 				for _, ct := range nc.ConfigTemplates {
-					if ct.File != "" {
+					if ct.File != "" && ct.PlatformCheck(cfg.GlobalSettings.Platform) {
 						fileSet[ct.File] = true
 					}
 				}
@@ -1241,7 +1290,9 @@ type Interface struct {
 	Connection *Connection
 	Opposite   *Interface
 	Neighbors  map[string][]*Neighbor
+	Segments   map[string]*NetworkSegment // key: layer name
 	NamePrefix string
+	AutoNamed  bool // name is assigned automatically with NamePrefix (not given in DOT)
 
 	*NameSpace
 	*ParsedLabels
@@ -1258,6 +1309,7 @@ func newInterface(node *Node, name string) *Interface {
 		Name:            name,
 		Node:            node,
 		Neighbors:       map[string][]*Neighbor{},
+		Segments:        map[string]*NetworkSegment{},
 		NameSpace:       newNameSpace(),
 		layerAwareObject: newLayerAwareObject(),
 		memberReference: newMemberReference(),
@@ -1285,6 +1337,20 @@ func (iface *Interface) SetLabels(cfg *Config, labels []string, moduleLabels []s
 	return nil
 }
 
+// AddClass adds a class to the interface after SetLabels (e.g., by classify rules).
+func (iface *Interface) AddClass(cfg *Config, name string) error {
+	if _, ok := cfg.InterfaceClassByName(name); !ok {
+		return fmt.Errorf("invalid interfaceclass name %s", name)
+	}
+	iface.ParsedLabels.addClassLabel(name)
+	iface.ParsedLabels.Classes = []ObjectClass{}
+	for _, cls := range iface.ClassLabels() {
+		ic, _ := cfg.InterfaceClassByName(cls)
+		iface.ParsedLabels.Classes = append(iface.ParsedLabels.Classes, ic)
+	}
+	return nil
+}
+
 func (iface *Interface) SetClasses(cfg *Config, nm *NetworkModel) error {
 	// Track conflicting values
 	seenValues := make(map[string]string)
@@ -1645,6 +1711,20 @@ func (conn *Connection) SetLabels(cfg *Config, labels []string, moduleLabels []s
 	return nil
 }
 
+// AddClass adds a class to the connection after SetLabels (e.g., by classify rules).
+func (conn *Connection) AddClass(cfg *Config, name string) error {
+	if _, ok := cfg.ConnectionClassByName(name); !ok {
+		return fmt.Errorf("invalid connectionclass name %s", name)
+	}
+	conn.ParsedLabels.addClassLabel(name)
+	conn.ParsedLabels.Classes = []ObjectClass{}
+	for _, cls := range conn.ClassLabels() {
+		cc, _ := cfg.ConnectionClassByName(cls)
+		conn.ParsedLabels.Classes = append(conn.ParsedLabels.Classes, cc)
+	}
+	return nil
+}
+
 func (conn *Connection) SetClasses(cfg *Config, nm *NetworkModel) error {
 	defaultConnectionLayer := cfg.DefaultConnectionLayer()
 	for _, layer := range defaultConnectionLayer {
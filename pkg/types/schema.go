@@ -0,0 +1,238 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+const JSONSchemaVersion = "https://json-schema.org/draft/2020-12/schema"
+
+// yamlFieldName returns the YAML key of a struct field.
+// Fields without yaml tag are internal and not available in config files.
+func yamlFieldName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+	tag, ok := field.Tag.Lookup("yaml")
+	if !ok {
+		return "", false
+	}
+	name := strings.Split(tag, ",")[0]
+	if name == "" || name == "-" {
+		return "", false
+	}
+	return name, true
+}
+
+// yamlFields returns the struct fields available in config files, keyed by YAML key.
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := map[string]reflect.StructField{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if name, ok := yamlFieldName(field); ok {
+			fields[name] = field
+		}
+	}
+	return fields
+}
+
+// checkUnknownFields validates that all keys in the YAML document are defined in the given type
+// (e.g., reflect.TypeOf(Config{})).
+// All unknown keys are reported at once, with a suggestion of the most similar key if any.
+func checkUnknownFields(data []byte, t reflect.Type) error {
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	msgs := []string{}
+	walkUnknownFields(doc, t, "", &msgs)
+	if len(msgs) > 0 {
+		return fmt.Errorf("invalid config: %s", strings.Join(msgs, "; "))
+	}
+	return nil
+}
+
+func walkUnknownFields(val interface{}, t reflect.Type, path string, msgs *[]string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := val.(map[string]interface{})
+		if !ok {
+			return
+		}
+		fields := yamlFields(t)
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if k == "<<" {
+				// merge key of YAML anchors
+				continue
+			}
+			field, ok := fields[k]
+			if !ok {
+				msg := fmt.Sprintf("unknown field %q in %s", k, pathForMessage(path))
+				if s := suggestFieldName(k, fields); s != "" {
+					msg += fmt.Sprintf(" (did you mean %s?)", s)
+				}
+				*msgs = append(*msgs, msg)
+				continue
+			}
+			walkUnknownFields(m[k], field.Type, joinFieldPath(path, k), msgs)
+		}
+	case reflect.Slice:
+		list, ok := val.([]interface{})
+		if !ok {
+			return
+		}
+		for i, item := range list {
+			walkUnknownFields(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), msgs)
+		}
+	case reflect.Map:
+		m, ok := val.(map[string]interface{})
+		if !ok {
+			return
+		}
+		for k, v := range m {
+			walkUnknownFields(v, t.Elem(), joinFieldPath(path, k), msgs)
+		}
+	}
+}
+
+func joinFieldPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func pathForMessage(path string) string {
+	if path == "" {
+		return "top level"
+	}
+	return path
+}
+
+// suggestFieldName returns the most similar field name to the given unknown key.
+// An empty string is returned if no field name is similar enough.
+func suggestFieldName(key string, fields map[string]reflect.StructField) string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	best := ""
+	bestDist := len(key)/3 + 1
+	if bestDist < 2 {
+		bestDist = 2
+	}
+	for _, name := range names {
+		if d := levenshtein(key, name); d <= bestDist && (best == "" || d < levenshtein(key, best)) {
+			best = name
+		}
+	}
+	return best
+}
+
+// levenshtein returns the edit distance between two strings.
+func levenshtein(a, b string) int {
+	ra := []rune(a)
+	rb := []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+// ConfigJSONSchema generates a JSON Schema of the config file (Config) for editor completion.
+func ConfigJSONSchema() ([]byte, error) {
+	defs := map[string]interface{}{}
+	root := jsonSchemaOf(reflect.TypeOf(Config{}), defs)
+	root["$schema"] = JSONSchemaVersion
+	root["title"] = "dot2net config"
+	root["$defs"] = defs
+	return json.MarshalIndent(root, "", "  ")
+}
+
+// jsonSchemaOf returns a JSON Schema of the type.
+// Struct types are registered in defs and referred with $ref, except for the Config itself.
+func jsonSchemaOf(t reflect.Type, defs map[string]interface{}) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if t == reflect.TypeOf(Config{}) {
+			return jsonSchemaOfStruct(t, defs)
+		}
+		if _, ok := defs[t.Name()]; !ok {
+			defs[t.Name()] = nil // placeholder for recursive types
+			defs[t.Name()] = jsonSchemaOfStruct(t, defs)
+		}
+		return map[string]interface{}{"$ref": "#/$defs/" + t.Name()}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{
+			"type":  "array",
+			"items": jsonSchemaOf(t.Elem(), defs),
+		}
+	case reflect.Map:
+		var items map[string]interface{}
+		if t.Elem().Kind() == reflect.String {
+			// scalar values in YAML (e.g., mtu: 1500) are accepted as strings
+			items = map[string]interface{}{"type": []string{"string", "number", "boolean"}}
+		} else {
+			items = jsonSchemaOf(t.Elem(), defs)
+		}
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": items,
+		}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	default:
+		// interface{} accepts any value
+		return map[string]interface{}{}
+	}
+}
+
+func jsonSchemaOfStruct(t reflect.Type, defs map[string]interface{}) map[string]interface{} {
+	props := map[string]interface{}{}
+	for name, field := range yamlFields(t) {
+		props[name] = jsonSchemaOf(field.Type, defs)
+	}
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           props,
+		"additionalProperties": false,
+	}
+}
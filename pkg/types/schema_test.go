@@ -0,0 +1,113 @@
+package types
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckUnknownFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		yaml     string
+		expected []string // substrings of the error, nil if no error
+	}{
+		{
+			name: "valid",
+			yaml: `
+name: test
+global:
+  path: local
+nodeclass:
+  - name: router
+    interface_policy: [p2p]
+    values:
+      mtu: 1500
+    config:
+      - file: frr.conf
+        template: ["hostname {{ .name }}"]
+`,
+		},
+		{
+			name: "typo_with_suggestion",
+			yaml: `
+nodeclass:
+  - name: router
+    interface_polciy: [p2p]
+`,
+			expected: []string{`unknown field "interface_polciy" in nodeclass[0]`, "did you mean interface_policy?"},
+		},
+		{
+			name: "nested_typo",
+			yaml: `
+layer:
+  - name: ip
+    policy:
+      - name: p2p
+        prefx: 30
+`,
+			expected: []string{`unknown field "prefx" in layer[0].policy[0]`, "did you mean prefix?"},
+		},
+		{
+			name:     "no_suggestion",
+			yaml:     "name: test\nfoobarbaz: 1\n",
+			expected: []string{`unknown field "foobarbaz" in top level`},
+		},
+		{
+			name:     "multiple",
+			yaml:     "nam: test\nglobl: {}\n",
+			expected: []string{"did you mean name?", "did you mean global?"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkUnknownFields([]byte(tt.yaml), reflect.TypeOf(Config{}))
+			if tt.expected == nil {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+			for _, s := range tt.expected {
+				if !strings.Contains(err.Error(), s) {
+					t.Errorf("error %q does not contain %q", err.Error(), s)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigJSONSchema(t *testing.T) {
+	buf, err := ConfigJSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema struct {
+		Properties map[string]map[string]interface{} `json:"properties"`
+		Defs       map[string]struct {
+			Properties           map[string]interface{} `json:"properties"`
+			AdditionalProperties bool                   `json:"additionalProperties"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(buf, &schema); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := schema.Properties["nodeclass"]; !ok {
+		t.Errorf("nodeclass is not in the schema")
+	}
+	nc, ok := schema.Defs["NodeClass"]
+	if !ok {
+		t.Fatalf("NodeClass is not in the schema definitions")
+	}
+	if _, ok := nc.Properties["interface_policy"]; !ok {
+		t.Errorf("interface_policy is not in NodeClass schema")
+	}
+	// internal fields are not exposed
+	if _, ok := schema.Defs["ConfigTemplate"].Properties["ParsedTemplate"]; ok {
+		t.Errorf("internal field is exposed in the schema")
+	}
+}
@@ -0,0 +1,379 @@
+package types
+
+import (
+	"fmt"
+	"math/big"
+	"net/netip"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// TemplateFuncs returns the functions available in config templates.
+// Parameters are given to templates as strings, so the functions accept both strings and integers.
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"ipAdd":          ipAdd,
+		"ipHost":         ipHost,
+		"prefixLen":      prefixLen,
+		"netmask":        netmask,
+		"wildcard":       wildcard,
+		"ipToInt":        ipToInt,
+		"v4ToV6Mapped":   v4ToV6Mapped,
+		"reverseName":    reverseName,
+		"subnet":         subnet,
+		"prefixContains": prefixContains,
+		"dict":           dict,
+		"macFormat":      macFormat,
+		"macToLinkLocal": macToLinkLocal,
+		"add":            add,
+		"sub":            sub,
+		"mul":            mul,
+		"div":            div,
+		"mod":            mod,
+	}
+}
+
+// NewTemplate returns an empty template with the template functions of dot2net.
+// Templates in configs (e.g., filters, offsets and param_format) should be parsed with it.
+func NewTemplate() *template.Template {
+	return template.New("").Funcs(TemplateFuncs())
+}
+
+// toInt converts a template argument (integer or string) into int.
+func toInt(v interface{}) (int, error) {
+	switch val := v.(type) {
+	case int:
+		return val, nil
+	case int64:
+		return int(val), nil
+	case string:
+		return strconv.Atoi(strings.TrimSpace(val))
+	default:
+		return 0, fmt.Errorf("invalid integer argument %v", v)
+	}
+}
+
+// parseAddrOrPrefix parses an address with or without prefix length (e.g., "10.0.0.1" or "10.0.0.1/24").
+// The prefix length is -1 if not given.
+func parseAddrOrPrefix(s string) (netip.Addr, int, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Addr{}, 0, err
+		}
+		return prefix.Addr(), prefix.Bits(), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, 0, err
+	}
+	return addr, -1, nil
+}
+
+func formatAddr(addr netip.Addr, bits int) string {
+	if bits < 0 {
+		return addr.String()
+	}
+	return netip.PrefixFrom(addr, bits).String()
+}
+
+func addrToBigInt(addr netip.Addr) *big.Int {
+	return new(big.Int).SetBytes(addr.AsSlice())
+}
+
+func bigIntToAddr(val *big.Int, is4 bool) (netip.Addr, error) {
+	size := 16
+	if is4 {
+		size = 4
+	}
+	if val.Sign() < 0 || val.BitLen() > size*8 {
+		return netip.Addr{}, fmt.Errorf("address out of range")
+	}
+	buf := make([]byte, size)
+	val.FillBytes(buf)
+	addr, _ := netip.AddrFromSlice(buf)
+	return addr, nil
+}
+
+// ipAdd returns the address added by n (n can be negative).
+// The prefix length is kept if given (e.g., ipAdd "10.0.0.1/24" 1 -> "10.0.0.2/24").
+func ipAdd(s string, n interface{}) (string, error) {
+	addr, bits, err := parseAddrOrPrefix(s)
+	if err != nil {
+		return "", err
+	}
+	offset, err := toInt(n)
+	if err != nil {
+		return "", err
+	}
+	val := addrToBigInt(addr)
+	val.Add(val, big.NewInt(int64(offset)))
+	ret, err := bigIntToAddr(val, addr.Is4())
+	if err != nil {
+		return "", fmt.Errorf("ipAdd %s %d: %w", s, offset, err)
+	}
+	return formatAddr(ret, bits), nil
+}
+
+// ipHost returns the n-th address in the prefix (e.g., ipHost "10.0.0.0/24" 1 -> "10.0.0.1").
+// A negative n counts from the last address of the prefix (e.g., -1 is the broadcast address in IPv4).
+func ipHost(s string, n interface{}) (string, error) {
+	prefix, err := netip.ParsePrefix(strings.TrimSpace(s))
+	if err != nil {
+		return "", err
+	}
+	idx, err := toInt(n)
+	if err != nil {
+		return "", err
+	}
+	prefix = prefix.Masked()
+	size := new(big.Int).Lsh(big.NewInt(1), uint(prefix.Addr().BitLen()-prefix.Bits()))
+	offset := big.NewInt(int64(idx))
+	if idx < 0 {
+		offset.Add(offset, size)
+	}
+	if offset.Sign() < 0 || offset.Cmp(size) >= 0 {
+		return "", fmt.Errorf("ipHost %s %d: index out of range", s, idx)
+	}
+	val := addrToBigInt(prefix.Addr())
+	val.Add(val, offset)
+	ret, err := bigIntToAddr(val, prefix.Addr().Is4())
+	if err != nil {
+		return "", err
+	}
+	return ret.String(), nil
+}
+
+// prefixLen returns the prefix length (e.g., prefixLen "10.0.0.0/24" -> 24).
+func prefixLen(s string) (int, error) {
+	prefix, err := netip.ParsePrefix(strings.TrimSpace(s))
+	if err != nil {
+		return 0, err
+	}
+	return prefix.Bits(), nil
+}
+
+// maskOf returns the network mask of a prefix or an IPv4 prefix length.
+func maskOf(v interface{}) (netip.Addr, error) {
+	var bits, bitlen int
+	if s, ok := v.(string); ok && strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(strings.TrimSpace(s))
+		if err != nil {
+			return netip.Addr{}, err
+		}
+		bits = prefix.Bits()
+		bitlen = prefix.Addr().BitLen()
+	} else {
+		var err error
+		bits, err = toInt(v)
+		if err != nil {
+			return netip.Addr{}, err
+		}
+		bitlen = 32
+		if bits < 0 || bits > bitlen {
+			return netip.Addr{}, fmt.Errorf("invalid prefix length %d", bits)
+		}
+	}
+	val := new(big.Int).Lsh(big.NewInt(1), uint(bitlen))
+	val.Sub(val, new(big.Int).Lsh(big.NewInt(1), uint(bitlen-bits)))
+	return bigIntToAddr(val, bitlen == 32)
+}
+
+// netmask returns the network mask of a prefix or an IPv4 prefix length
+// (e.g., netmask "10.0.0.0/24" -> "255.255.255.0").
+func netmask(v interface{}) (string, error) {
+	mask, err := maskOf(v)
+	if err != nil {
+		return "", err
+	}
+	return mask.String(), nil
+}
+
+// wildcard returns the wildcard (inverse) mask of a prefix or an IPv4 prefix length
+// (e.g., wildcard "10.0.0.0/24" -> "0.0.0.255").
+func wildcard(v interface{}) (string, error) {
+	mask, err := maskOf(v)
+	if err != nil {
+		return "", err
+	}
+	buf := mask.AsSlice()
+	for i := range buf {
+		buf[i] = ^buf[i]
+	}
+	addr, _ := netip.AddrFromSlice(buf)
+	return addr.String(), nil
+}
+
+// ipToInt returns the address as a decimal integer string (e.g., ipToInt "0.0.1.1" -> "257").
+func ipToInt(s string) (string, error) {
+	addr, _, err := parseAddrOrPrefix(s)
+	if err != nil {
+		return "", err
+	}
+	return addrToBigInt(addr).String(), nil
+}
+
+// v4ToV6Mapped returns the IPv4-mapped IPv6 address (e.g., v4ToV6Mapped "10.0.0.1" -> "::ffff:10.0.0.1").
+// The prefix length is converted if given (e.g., /24 -> /120).
+func v4ToV6Mapped(s string) (string, error) {
+	addr, bits, err := parseAddrOrPrefix(s)
+	if err != nil {
+		return "", err
+	}
+	if !addr.Is4() {
+		return "", fmt.Errorf("v4ToV6Mapped: %s is not an IPv4 address", s)
+	}
+	if bits >= 0 {
+		bits += 96
+	}
+	return formatAddr(netip.AddrFrom16(addr.As16()), bits), nil
+}
+
+// reverseName returns the reverse DNS name of the address
+// (e.g., reverseName "10.0.0.1" -> "1.0.0.10.in-addr.arpa").
+func reverseName(s string) (string, error) {
+	addr, _, err := parseAddrOrPrefix(s)
+	if err != nil {
+		return "", err
+	}
+	return ReverseName(addr), nil
+}
+
+// ReverseName returns the reverse DNS name of the address without the trailing dot
+// (in-addr.arpa for IPv4 and ip6.arpa for IPv6).
+func ReverseName(addr netip.Addr) string {
+	labels := []string{}
+	buf := addr.AsSlice()
+	if addr.Is4() {
+		for i := len(buf) - 1; i >= 0; i-- {
+			labels = append(labels, strconv.Itoa(int(buf[i])))
+		}
+		labels = append(labels, "in-addr", "arpa")
+	} else {
+		for i := len(buf) - 1; i >= 0; i-- {
+			labels = append(labels, strconv.FormatInt(int64(buf[i]&0x0f), 16), strconv.FormatInt(int64(buf[i]>>4), 16))
+		}
+		labels = append(labels, "ip6", "arpa")
+	}
+	return strings.Join(labels, ".")
+}
+
+// subnet returns the idx-th subnet of length newlen in the prefix
+// (e.g., subnet "10.0.0.0/16" 24 3 -> "10.0.3.0/24").
+func subnet(s string, newlen interface{}, idx interface{}) (string, error) {
+	prefix, err := netip.ParsePrefix(strings.TrimSpace(s))
+	if err != nil {
+		return "", err
+	}
+	bits, err := toInt(newlen)
+	if err != nil {
+		return "", err
+	}
+	i, err := toInt(idx)
+	if err != nil {
+		return "", err
+	}
+	prefix = prefix.Masked()
+	bitlen := prefix.Addr().BitLen()
+	if bits < prefix.Bits() || bits > bitlen {
+		return "", fmt.Errorf("subnet %s: invalid prefix length %d", s, bits)
+	}
+	num := new(big.Int).Lsh(big.NewInt(1), uint(bits-prefix.Bits()))
+	if i < 0 || big.NewInt(int64(i)).Cmp(num) >= 0 {
+		return "", fmt.Errorf("subnet %s %d: index %d out of range", s, bits, i)
+	}
+	val := addrToBigInt(prefix.Addr())
+	val.Add(val, new(big.Int).Lsh(big.NewInt(int64(i)), uint(bitlen-bits)))
+	addr, err := bigIntToAddr(val, prefix.Addr().Is4())
+	if err != nil {
+		return "", err
+	}
+	return netip.PrefixFrom(addr, bits).String(), nil
+}
+
+// prefixContains returns true if the prefix contains the address
+// (e.g., prefixContains "10.0.0.0/24" "10.0.0.1" -> true).
+func prefixContains(p string, s string) (bool, error) {
+	prefix, err := netip.ParsePrefix(strings.TrimSpace(p))
+	if err != nil {
+		return false, err
+	}
+	addr, _, err := parseAddrOrPrefix(s)
+	if err != nil {
+		return false, err
+	}
+	return prefix.Masked().Contains(addr), nil
+}
+
+// dict returns a map of given key-value pairs, used to give multiple arguments to shared templates
+// (e.g., {{ template "neighbor" dict "addr" .ipv4_addr "as" .as }}).
+func dict(kvs ...interface{}) (map[string]interface{}, error) {
+	if len(kvs)%2 != 0 {
+		return nil, fmt.Errorf("dict: odd number of arguments")
+	}
+	ret := make(map[string]interface{}, len(kvs)/2)
+	for i := 0; i < len(kvs); i += 2 {
+		key, ok := kvs[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("dict: key %v is not a string", kvs[i])
+		}
+		ret[key] = kvs[i+1]
+	}
+	return ret, nil
+}
+
+// add returns a + b. Arguments can be integers or strings (e.g., add .pod_index 100).
+func add(a, b interface{}) (int, error) {
+	x, y, err := toInts(a, b)
+	return x + y, err
+}
+
+// sub returns a - b.
+func sub(a, b interface{}) (int, error) {
+	x, y, err := toInts(a, b)
+	return x - y, err
+}
+
+// mul returns a * b.
+func mul(a, b interface{}) (int, error) {
+	x, y, err := toInts(a, b)
+	return x * y, err
+}
+
+// div returns a / b (integer division).
+func div(a, b interface{}) (int, error) {
+	x, y, err := toInts(a, b)
+	if err != nil {
+		return 0, err
+	}
+	if y == 0 {
+		return 0, fmt.Errorf("div: division by zero")
+	}
+	return x / y, nil
+}
+
+// mod returns a % b.
+func mod(a, b interface{}) (int, error) {
+	x, y, err := toInts(a, b)
+	if err != nil {
+		return 0, err
+	}
+	if y == 0 {
+		return 0, fmt.Errorf("mod: division by zero")
+	}
+	return x % y, nil
+}
+
+func toInts(a, b interface{}) (int, int, error) {
+	x, err := toInt(a)
+	if err != nil {
+		return 0, 0, err
+	}
+	y, err := toInt(b)
+	if err != nil {
+		return 0, 0, err
+	}
+	return x, y, nil
+}
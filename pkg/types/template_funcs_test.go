@@ -0,0 +1,88 @@
+package types
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTemplateFuncs(t *testing.T) {
+	params := map[string]string{
+		"ipv4_addr": "10.0.1.5",
+		"ipv4_net":  "10.0.1.0/24",
+		"ipv4_plen": "24",
+		"ipv6_net":  "2001:db8::/64",
+	}
+	tests := []struct {
+		tpl      string
+		expected string
+	}{
+		{`{{ ipAdd .ipv4_addr 1 }}`, "10.0.1.6"},
+		{`{{ ipAdd "10.0.1.0/24" -1 }}`, "10.0.0.255/24"},
+		{`{{ ipAdd "2001:db8::ffff" 1 }}`, "2001:db8::1:0"},
+		{`{{ ipHost .ipv4_net 1 }}`, "10.0.1.1"},
+		{`{{ ipHost .ipv4_net -2 }}`, "10.0.1.254"},
+		{`{{ ipHost .ipv6_net "1" }}`, "2001:db8::1"},
+		{`{{ prefixLen .ipv4_net }}`, "24"},
+		{`{{ netmask .ipv4_net }}`, "255.255.255.0"},
+		{`{{ netmask .ipv4_plen }}`, "255.255.255.0"},
+		{`{{ netmask 30 }}`, "255.255.255.252"},
+		{`{{ wildcard .ipv4_net }}`, "0.0.0.255"},
+		{`{{ wildcard .ipv6_net }}`, "::ffff:ffff:ffff:ffff"},
+		{`{{ ipToInt "0.0.1.1" }}`, "257"},
+		{`{{ ipToInt "::1:0" }}`, "65536"},
+		{`{{ v4ToV6Mapped .ipv4_addr }}`, "::ffff:10.0.1.5"},
+		{`{{ v4ToV6Mapped .ipv4_net }}`, "::ffff:10.0.1.0/120"},
+		{`{{ reverseName .ipv4_addr }}`, "5.1.0.10.in-addr.arpa"},
+		{`{{ reverseName "2001:db8::1" }}`, "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa"},
+		{`{{ subnet "10.0.0.0/16" 24 3 }}`, "10.0.3.0/24"},
+		{`{{ subnet .ipv6_net 80 "2" }}`, "2001:db8:0:0:2::/80"},
+		{`{{ prefixContains .ipv4_net .ipv4_addr }}`, "true"},
+		{`{{ if prefixContains .ipv4_net "10.0.2.1" }}yes{{ else }}no{{ end }}`, "no"},
+		{`{{ $d := dict "addr" .ipv4_addr "plen" 24 }}{{ $d.addr }}/{{ $d.plen }}`, "10.0.1.5/24"},
+		{`{{ macFormat "02:42:ac:11:00:01" "cisco" }}`, "0242.ac11.0001"},
+		{`{{ macFormat "0242.ac11.0001" "dash" }}`, "02-42-ac-11-00-01"},
+		{`{{ macToLinkLocal "02:42:ac:11:00:01" }}`, "fe80::42:acff:fe11:1"},
+		{`{{ mul (sub .ipv4_plen 1) 100 }}`, "2300"},
+		{`{{ add (div 7 2) (mod 7 2) }}`, "4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tpl, func(t *testing.T) {
+			tpl, err := NewTemplate().Parse(tt.tpl)
+			if err != nil {
+				t.Fatal(err)
+			}
+			buf := new(strings.Builder)
+			if err := tpl.Execute(buf, params); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, buf.String())
+			}
+		})
+	}
+
+	errTests := []string{
+		`{{ ipAdd "255.255.255.255" 1 }}`,
+		`{{ ipHost "10.0.0.0/30" 4 }}`,
+		`{{ subnet "10.0.0.0/24" 16 0 }}`,
+		`{{ subnet "10.0.0.0/24" 26 4 }}`,
+		`{{ v4ToV6Mapped "2001:db8::1" }}`,
+		`{{ netmask "abc" }}`,
+		`{{ dict "addr" }}`,
+		`{{ macFormat "02:42:ac:11:00:01" "dotted" }}`,
+		`{{ macToLinkLocal "02:42:ac" }}`,
+		`{{ div 1 0 }}`,
+		`{{ add "a" 1 }}`,
+	}
+	for _, tplString := range errTests {
+		t.Run(tplString, func(t *testing.T) {
+			tpl, err := NewTemplate().Parse(tplString)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := tpl.Execute(new(strings.Builder), params); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package types
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// ObjectValueSeparator separates node name and interface name in keys of interface values (e.g., r1:eth0).
+const ObjectValueSeparator = ":"
+
+// objectValuesFile is the format of the object values file.
+// Each section maps object names to key/value maps.
+type objectValuesFile struct {
+	Nodes       map[string]map[string]interface{} `yaml:"node"`
+	Interfaces  map[string]map[string]interface{} `yaml:"interface"`
+	Connections map[string]map[string]interface{} `yaml:"connection"`
+	Groups      map[string]map[string]interface{} `yaml:"group"`
+}
+
+// ObjectValues holds per-object values given in the object values file (object_values).
+// Keys of interface values are "node:interface".
+type ObjectValues struct {
+	Nodes       map[string]map[string]string
+	Interfaces  map[string]map[string]string
+	Connections map[string]map[string]string
+	Groups      map[string]map[string]string
+}
+
+// LoadObjectValues loads the object values file.
+func LoadObjectValues(path string) (*ObjectValues, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	err = checkUnknownFields(bytes, reflect.TypeOf(objectValuesFile{}))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	raw := objectValuesFile{}
+	err = yaml.Unmarshal(bytes, &raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	ov := &ObjectValues{}
+	if ov.Nodes, err = stringifyObjectValues(raw.Nodes); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if ov.Interfaces, err = stringifyObjectValues(raw.Interfaces); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for key := range ov.Interfaces {
+		if !strings.Contains(key, ObjectValueSeparator) {
+			return nil, fmt.Errorf("%s: interface key %s should be node%sinterface", path, key, ObjectValueSeparator)
+		}
+	}
+	if ov.Connections, err = stringifyObjectValues(raw.Connections); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if ov.Groups, err = stringifyObjectValues(raw.Groups); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return ov, nil
+}
+
+// stringifyObjectValues converts values into strings.
+// List values are joined with a space in the same way as list labels (e.g., [a, b] -> "a b").
+func stringifyObjectValues(raw map[string]map[string]interface{}) (map[string]map[string]string, error) {
+	ret := make(map[string]map[string]string, len(raw))
+	for name, values := range raw {
+		ret[name] = make(map[string]string, len(values))
+		for k, v := range values {
+			switch val := v.(type) {
+			case nil:
+				ret[name][k] = ""
+			case []interface{}:
+				items := make([]string, 0, len(val))
+				for _, item := range val {
+					items = append(items, fmt.Sprint(item))
+				}
+				ret[name][k] = strings.Join(items, " ")
+			case map[string]interface{}:
+				return nil, fmt.Errorf("value %s of %s should not be a map", k, name)
+			default:
+				ret[name][k] = fmt.Sprint(val)
+			}
+		}
+	}
+	return ret, nil
+}
+
+// InterfaceValueKey returns the key of interface values in the object values file.
+func InterfaceValueKey(nodeName, ifaceName string) string {
+	return nodeName + ObjectValueSeparator + ifaceName
+}
+
+// ValuesOf returns the given values of the object. Nil is returned if no values are given.
+func (ov *ObjectValues) ValuesOf(lo LabelOwner) map[string]string {
+	if ov == nil {
+		return nil
+	}
+	switch obj := lo.(type) {
+	case *Node:
+		return ov.Nodes[obj.Name]
+	case *Interface:
+		return ov.Interfaces[InterfaceValueKey(obj.Node.Name, obj.Name)]
+	case *Connection:
+		return ov.Connections[obj.Name]
+	case *Group:
+		return ov.Groups[obj.Name]
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,335 @@
+package visual
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"sort"
+	"strings"
+
+	"github.com/cpflat/dot2net/pkg/types"
+)
+
+// IPAM record types
+const (
+	IPAMRecordLoopback   = "loopback"
+	IPAMRecordInterface  = "interface"
+	IPAMRecordSegment    = "segment"
+	IPAMRecordVIP        = "vip"
+	IPAMRecordManagement = "management"
+)
+
+// DefaultIPAMDomain is the default DNS domain of the IPAM report (e.g., r1-eth0.ip.lab.)
+const DefaultIPAMDomain = "lab"
+
+// An IPAMRecord is an assigned address (or segment prefix) in the IPAM report.
+type IPAMRecord struct {
+	Layer     string `json:"layer"`
+	Type      string `json:"type"`
+	Node      string `json:"node,omitempty"`
+	Interface string `json:"interface,omitempty"`
+	Segment   string `json:"segment,omitempty"`
+	Address   string `json:"address,omitempty"`
+	Prefix    string `json:"prefix"`
+	// Name is the DNS name of the address (empty for segments)
+	Name string `json:"name,omitempty"`
+}
+
+// dnsLabelMaxLength is the maximum length of a DNS label in octets (RFC 1035)
+const dnsLabelMaxLength = 63
+
+var ipamCSVHeader = []string{"layer", "type", "node", "interface", "segment", "address", "prefix", "name"}
+
+// dnsLabel converts the name into a DNS label (lower letters, digits and hyphens).
+func dnsLabel(name string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			sb.WriteRune(r)
+		} else {
+			sb.WriteRune('-')
+		}
+	}
+	return strings.Trim(sb.String(), "-")
+}
+
+// ipamName returns the DNS name of the object in the layer (e.g., r1-eth0.ip.lab.).
+// It returns an error if a name has no characters available in DNS labels,
+// or if a label is longer than 63 octets.
+func ipamName(domain string, layer string, labels ...string) (string, error) {
+	names := make([]string, 0, len(labels))
+	for _, label := range append(labels, layer) {
+		name := dnsLabel(label)
+		if name == "" {
+			return "", fmt.Errorf("name %q cannot be converted into a DNS label", label)
+		}
+		names = append(names, name)
+	}
+	host := strings.Join(names[:len(labels)], "-")
+	for _, label := range []string{host, names[len(labels)]} {
+		if len(label) > dnsLabelMaxLength {
+			return "", fmt.Errorf("DNS label %s is longer than %d octets", label, dnsLabelMaxLength)
+		}
+	}
+	return host + "." + names[len(labels)] + "." + strings.TrimSuffix(domain, ".") + ".", nil
+}
+
+// checkIPAMNames returns an error if different records are converted into the same DNS name
+// (e.g., loopback of node r1-eth0 and interface eth0 of node r1).
+func checkIPAMNames(records []*IPAMRecord) error {
+	names := map[string]*IPAMRecord{}
+	for _, r := range records {
+		if r.Name == "" {
+			continue
+		}
+		if other, ok := names[r.Name]; ok {
+			return fmt.Errorf("duplicated DNS name %s for %s and %s", r.Name, other.describe(), r.describe())
+		}
+		names[r.Name] = r
+	}
+	return nil
+}
+
+// describe returns the object of the record for messages (e.g., interface r1.eth0 in layer ip).
+func (r *IPAMRecord) describe() string {
+	switch {
+	case r.Interface != "":
+		return fmt.Sprintf("%s %s.%s in layer %s", r.Type, r.Node, r.Interface, r.Layer)
+	case r.Node != "":
+		return fmt.Sprintf("%s %s in layer %s", r.Type, r.Node, r.Layer)
+	default:
+		return fmt.Sprintf("%s %s in layer %s", r.Type, r.Segment, r.Layer)
+	}
+}
+
+// GetIPAMRecords lists the assigned loopbacks, interface addresses, segment prefixes (and VIPs) and management addresses.
+// If layer is given, only the records of the layer are listed.
+func GetIPAMRecords(cfg *types.Config, nm *types.NetworkModel, layer string, domain string) ([]*IPAMRecord, error) {
+	if domain == "" {
+		domain = DefaultIPAMDomain
+	}
+	records := []*IPAMRecord{}
+
+	if cfg.HasManagementLayer() && (layer == "" || layer == cfg.ManagementLayer.Name) {
+		mlayer := &cfg.ManagementLayer
+		for _, node := range nm.Nodes {
+			iface := node.GetManagementInterface()
+			if iface == nil {
+				continue
+			}
+			addr, err := iface.GetParamValue(mlayer.IPAddressReplacer())
+			if err != nil {
+				continue
+			}
+			network, _ := iface.GetParamValue(mlayer.IPNetworkReplacer())
+			name, err := ipamName(domain, mlayer.Name, node.Name)
+			if err != nil {
+				return nil, err
+			}
+			records = append(records, &IPAMRecord{
+				Layer:     mlayer.Name,
+				Type:      IPAMRecordManagement,
+				Node:      node.Name,
+				Interface: iface.Name,
+				Address:   addr,
+				Prefix:    network,
+				Name:      name,
+			})
+		}
+	}
+
+	found := layer == "" || (cfg.HasManagementLayer() && layer == cfg.ManagementLayer.Name)
+	for _, l := range cfg.Layers {
+		if layer != "" && l.Name != layer {
+			continue
+		}
+		found = true
+		for _, node := range nm.Nodes {
+			if addr, err := getNodeLoopback(node, l); err == nil {
+				prefix, err := netip.ParseAddr(addr)
+				if err != nil {
+					return nil, fmt.Errorf("invalid loopback address %s of %s", addr, node.Name)
+				}
+				name, err := ipamName(domain, l.Name, node.Name)
+				if err != nil {
+					return nil, err
+				}
+				records = append(records, &IPAMRecord{
+					Layer:   l.Name,
+					Type:    IPAMRecordLoopback,
+					Node:    node.Name,
+					Address: addr,
+					Prefix:  netip.PrefixFrom(prefix, prefix.BitLen()).String(),
+					Name:    name,
+				})
+			}
+			for _, iface := range node.Interfaces {
+				if !iface.AwareLayer(l.Name) {
+					continue
+				}
+				addr, err := getInterfaceAddress(iface, l)
+				if err != nil {
+					continue
+				}
+				network, _ := iface.GetParamValue(l.IPNetworkReplacer())
+				name, err := ipamName(domain, l.Name, node.Name, iface.Name)
+				if err != nil {
+					return nil, err
+				}
+				record := &IPAMRecord{
+					Layer:     l.Name,
+					Type:      IPAMRecordInterface,
+					Node:      node.Name,
+					Interface: iface.Name,
+					Address:   addr,
+					Prefix:    network,
+					Name:      name,
+				}
+				if seg, ok := iface.Segments[l.Name]; ok {
+					record.Segment = seg.Name
+				}
+				records = append(records, record)
+			}
+		}
+		for _, seg := range nm.NetworkSegments[l.Name] {
+			if len(seg.Interfaces) == 0 {
+				continue
+			}
+			network, err := seg.Interfaces[0].GetParamValue(l.IPNetworkReplacer())
+			if err != nil {
+				continue
+			}
+			records = append(records, &IPAMRecord{
+				Layer:   l.Name,
+				Type:    IPAMRecordSegment,
+				Segment: seg.Name,
+				Prefix:  network,
+			})
+			if vip, err := seg.GetParamValue(types.SegmentVIPKey); err == nil {
+				name, err := ipamName(domain, l.Name, seg.Name, IPAMRecordVIP)
+				if err != nil {
+					return nil, err
+				}
+				records = append(records, &IPAMRecord{
+					Layer:   l.Name,
+					Type:    IPAMRecordVIP,
+					Segment: seg.Name,
+					Address: vip,
+					Prefix:  network,
+					Name:    name,
+				})
+			}
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("unknown layer %s", layer)
+	}
+	if err := checkIPAMNames(records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// IPAMRecordsToCSV formats the IPAM records in CSV with a header line.
+func IPAMRecordsToCSV(records []*IPAMRecord) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w := csv.NewWriter(buf)
+	if err := w.Write(ipamCSVHeader); err != nil {
+		return nil, err
+	}
+	for _, r := range records {
+		row := []string{r.Layer, r.Type, r.Node, r.Interface, r.Segment, r.Address, r.Prefix, r.Name}
+		if err := w.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// IPAMRecordsToJSON formats the IPAM records in JSON.
+func IPAMRecordsToJSON(records []*IPAMRecord) ([]byte, error) {
+	return json.MarshalIndent(records, "", "  ")
+}
+
+// IPAMRecordsToHosts formats the named IPAM records as an /etc/hosts fragment.
+// The short name without the domain is given as an alias.
+func IPAMRecordsToHosts(records []*IPAMRecord, domain string) []byte {
+	if domain == "" {
+		domain = DefaultIPAMDomain
+	}
+	suffix := "." + strings.TrimSuffix(domain, ".") + "."
+	buf := &bytes.Buffer{}
+	for _, r := range records {
+		if r.Name == "" {
+			continue
+		}
+		fqdn := strings.TrimSuffix(r.Name, ".")
+		short := strings.TrimSuffix(r.Name, suffix)
+		fmt.Fprintf(buf, "%s\t%s %s\n", r.Address, fqdn, short)
+	}
+	return buf.Bytes()
+}
+
+// zoneHeader returns SOA and NS records of a zone file.
+func zoneHeader(origin string) string {
+	return fmt.Sprintf("$ORIGIN %s\n$TTL 3600\n@\tIN\tSOA\tlocalhost. root.localhost. (1 3600 900 604800 3600)\n@\tIN\tNS\tlocalhost.\n", origin)
+}
+
+// IPAMRecordsToZones generates the forward zone file of the domain, and the reverse zone files
+// (in-addr.arpa for IPv4 and ip6.arpa for IPv6) of the named IPAM records.
+// The returned map is keyed by the zone file names (db.<domain>, db.in-addr.arpa and db.ip6.arpa).
+func IPAMRecordsToZones(records []*IPAMRecord, domain string) (map[string][]byte, error) {
+	if domain == "" {
+		domain = DefaultIPAMDomain
+	}
+	origin := strings.TrimSuffix(domain, ".") + "."
+	forward := &bytes.Buffer{}
+	forward.WriteString(zoneHeader(origin))
+	reverse := map[string]*bytes.Buffer{}
+	ptrs := map[string]map[string]string{}
+
+	for _, r := range records {
+		if r.Name == "" {
+			continue
+		}
+		addr, err := netip.ParseAddr(r.Address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %s of %s", r.Address, r.Name)
+		}
+		rtype := "A"
+		zone := "in-addr.arpa."
+		if addr.Is6() {
+			rtype = "AAAA"
+			zone = "ip6.arpa."
+		}
+		fmt.Fprintf(forward, "%s\tIN\t%s\t%s\n", strings.TrimSuffix(r.Name, "."+origin), rtype, addr)
+
+		if _, ok := reverse[zone]; !ok {
+			reverse[zone] = &bytes.Buffer{}
+			reverse[zone].WriteString(zoneHeader(zone))
+			ptrs[zone] = map[string]string{}
+		}
+		// an address has one PTR record (the first name)
+		ptr := types.ReverseName(addr) + "."
+		if _, ok := ptrs[zone][ptr]; !ok {
+			ptrs[zone][ptr] = r.Name
+		}
+	}
+
+	zones := map[string][]byte{"db." + strings.TrimSuffix(origin, "."): forward.Bytes()}
+	for zone, buf := range reverse {
+		names := make([]string, 0, len(ptrs[zone]))
+		for name := range ptrs[zone] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(buf, "%s\tIN\tPTR\t%s\n", name, ptrs[zone][name])
+		}
+		zones["db."+strings.TrimSuffix(zone, ".")] = buf.Bytes()
+	}
+	return zones, nil
+}